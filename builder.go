@@ -0,0 +1,124 @@
+package senml
+
+import "time"
+
+// PackBuilder builds packs with a fluent API.
+// Base fields apply to the whole pack, names of measurements are relative to the base name,
+// and measurements with a zero time.Time have no time (i.e. the base time, or the time of reception).
+//
+//	p := senml.NewPackBuilder().
+//		BaseName("urn:dev:ow:10e2073a01080063:").
+//		BaseTime(now).
+//		BaseUnit(senml.Celsius).
+//		Float("temp", "", 23.1, time.Time{}).
+//		Bool("open", true, now.Add(time.Minute)).
+//		Compact()
+type PackBuilder struct {
+	baseName string
+	baseTime time.Time
+	baseUnit Unit
+	records  []Record
+}
+
+// NewPackBuilder returns an empty PackBuilder.
+func NewPackBuilder() *PackBuilder {
+	return &PackBuilder{}
+}
+
+// BaseName sets the base name of the pack.
+func (b *PackBuilder) BaseName(name string) *PackBuilder {
+	b.baseName = name
+	return b
+}
+
+// BaseTime sets the base time of the pack.
+func (b *PackBuilder) BaseTime(t time.Time) *PackBuilder {
+	b.baseTime = t
+	return b
+}
+
+// BaseUnit sets the base unit of the pack. It applies to the numeric measurements without unit.
+func (b *PackBuilder) BaseUnit(u Unit) *PackBuilder {
+	b.baseUnit = u
+	return b
+}
+
+// Float appends a numeric value. If unit is empty, the base unit applies.
+func (b *PackBuilder) Float(name string, unit Unit, v float64, t time.Time) *PackBuilder {
+	return b.add(Record{Name: name, Unit: unit, Value: Float(v)}, t)
+}
+
+// Sum appends an integrated value. If unit is empty, the base unit applies.
+func (b *PackBuilder) Sum(name string, unit Unit, s float64, t time.Time) *PackBuilder {
+	return b.add(Record{Name: name, Unit: unit, Sum: Float(s)}, t)
+}
+
+// Bool appends a boolean value.
+func (b *PackBuilder) Bool(name string, v bool, t time.Time) *PackBuilder {
+	return b.add(Record{Name: name, BoolValue: Bool(v)}, t)
+}
+
+// String appends a string value.
+func (b *PackBuilder) String(name string, v string, t time.Time) *PackBuilder {
+	return b.add(Record{Name: name, StringValue: v}, t)
+}
+
+// Data appends a data value. The data is not copied.
+func (b *PackBuilder) Data(name string, v []byte, t time.Time) *PackBuilder {
+	if v == nil {
+		v = []byte{}
+	}
+	return b.add(Record{Name: name, DataValue: v}, t)
+}
+
+func (b *PackBuilder) add(r Record, t time.Time) *PackBuilder {
+	if !t.IsZero() {
+		r.Time = Time(t)
+	}
+	b.records = append(b.records, r)
+	return b
+}
+
+// Compact returns the pack, with the base fields set on the first record (a pack without measurements is empty),
+// and times relative to the base time.
+// As the base unit would also apply to the boolean, string and data values, it is only set if all measurements
+// are numeric, and units equal to it are omitted. Otherwise, it is set as the unit of the numeric measurements without unit.
+func (b *PackBuilder) Compact() Pack {
+	var btime float64
+	if !b.baseTime.IsZero() {
+		btime = Time(b.baseTime)
+	}
+	numeric := true
+	for _, r := range b.records {
+		if r.Value == nil && r.Sum == nil {
+			numeric = false
+			break
+		}
+	}
+	p := make(Pack, len(b.records))
+	for i, r := range b.records {
+		if r.Time != 0 {
+			r.Time -= btime
+		}
+		switch {
+		case numeric && r.Unit == b.baseUnit:
+			r.Unit = ""
+		case !numeric && r.Unit == "" && (r.Value != nil || r.Sum != nil):
+			r.Unit = b.baseUnit
+		}
+		p[i] = r
+	}
+	if len(p) > 0 {
+		p[0].BaseName = b.baseName
+		p[0].BaseTime = btime
+		if numeric {
+			p[0].BaseUnit = b.baseUnit
+		}
+	}
+	return p
+}
+
+// Normalized returns the resolved pack, as returned by Pack.Normalize.
+func (b *PackBuilder) Normalized() Pack {
+	return b.Compact().Normalize()
+}
@@ -0,0 +1,73 @@
+package senml
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPackBuilder(t *testing.T) {
+	now := time.Date(2011, 10, 31, 13, 24, 24, 0, time.UTC)
+	b := NewPackBuilder().
+		BaseName("urn:dev:ow:10e2073a01080063:").
+		BaseTime(now).
+		BaseUnit(Celsius).
+		Float("temp", "", 23.1, time.Time{}).
+		Float("temp", Celsius, 23.4, now.Add(-time.Minute)).
+		Sum("energy", Joule, 1200, now.Add(time.Minute)).
+		Bool("open", true, now).
+		String("label", "kitchen", now.Add(time.Second)).
+		Data("raw", []byte{0xca, 0xfe}, time.Time{})
+
+	compact := Pack{
+		{BaseName: "urn:dev:ow:10e2073a01080063:", BaseTime: 1.320067464e+09, Name: "temp", Unit: Celsius, Value: Float(23.1)},
+		{Name: "temp", Unit: Celsius, Time: -60, Value: Float(23.4)},
+		{Name: "energy", Unit: Joule, Time: 60, Sum: Float(1200)},
+		{Name: "open", BoolValue: True},
+		{Name: "label", Time: 1, StringValue: "kitchen"},
+		{Name: "raw", DataValue: []byte{0xca, 0xfe}},
+	}
+	if p := b.Compact(); !reflect.DeepEqual(p, compact) {
+		t.Errorf("compact pack should be %+v not %+v", compact, p)
+	}
+	normalized := Pack{
+		{Name: "urn:dev:ow:10e2073a01080063:temp", Unit: Celsius, Time: 1.320067404e+09, Value: Float(23.4)},
+		{Name: "urn:dev:ow:10e2073a01080063:temp", Unit: Celsius, Time: 1.320067464e+09, Value: Float(23.1)},
+		{Name: "urn:dev:ow:10e2073a01080063:open", Time: 1.320067464e+09, BoolValue: True},
+		{Name: "urn:dev:ow:10e2073a01080063:raw", Time: 1.320067464e+09, DataValue: []byte{0xca, 0xfe}},
+		{Name: "urn:dev:ow:10e2073a01080063:label", Time: 1.320067465e+09, StringValue: "kitchen"},
+		{Name: "urn:dev:ow:10e2073a01080063:energy", Unit: Joule, Time: 1.320067524e+09, Sum: Float(1200)},
+	}
+	if p := b.Normalized(); !reflect.DeepEqual(p, normalized) {
+		t.Errorf("normalized pack should be %+v not %+v", normalized, p)
+	}
+
+	// numeric measurements only
+	p := NewPackBuilder().
+		BaseUnit(Celsius).
+		Float("temp", "", 23.1, time.Time{}).
+		Float("temp", Celsius, 23.4, time.Time{}).
+		Sum("energy", Joule, 1200, time.Time{}).
+		Compact()
+	exp := Pack{
+		{BaseUnit: Celsius, Name: "temp", Value: Float(23.1)},
+		{Name: "temp", Value: Float(23.4)},
+		{Name: "energy", Unit: Joule, Sum: Float(1200)},
+	}
+	if !reflect.DeepEqual(p, exp) {
+		t.Errorf("compact pack should be %+v not %+v", exp, p)
+	}
+
+	// without base fields
+	p = NewPackBuilder().Float("urn:dev:ow:10e2073a01080063:temp", Celsius, 23.1, now).Data("raw", nil, time.Time{}).Compact()
+	exp = Pack{
+		{Name: "urn:dev:ow:10e2073a01080063:temp", Unit: Celsius, Time: 1.320067464e+09, Value: Float(23.1)},
+		{Name: "raw", DataValue: []byte{}},
+	}
+	if !reflect.DeepEqual(p, exp) {
+		t.Errorf("compact pack should be %+v not %+v", exp, p)
+	}
+	if p := NewPackBuilder().BaseName("foo").Compact(); len(p) != 0 {
+		t.Errorf("compact pack without measurements should be empty, not %+v", p)
+	}
+}
@@ -0,0 +1,255 @@
+package coap
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+)
+
+// Type is the CoAP message type.
+type Type uint8
+
+// CoAP message types, as defined in https://tools.ietf.org/html/rfc7252#section-3.
+const (
+	Confirmable     Type = 0
+	NonConfirmable  Type = 1
+	Acknowledgement Type = 2
+	Reset           Type = 3
+)
+
+// Code is the CoAP message code (class.detail).
+type Code uint8
+
+// CoAP method and response codes used with SenML resources.
+const (
+	GET    Code = 0x01
+	POST   Code = 0x02
+	PUT    Code = 0x03
+	DELETE Code = 0x04
+
+	Created                  Code = 0x41
+	Deleted                  Code = 0x42
+	Valid                    Code = 0x43
+	Changed                  Code = 0x44
+	Content                  Code = 0x45
+	BadRequest               Code = 0x80
+	NotFound                 Code = 0x84
+	MethodNotAllowed         Code = 0x85
+	NotAcceptable            Code = 0x86
+	UnsupportedContentFormat Code = 0x8f
+	InternalServerError      Code = 0xa0
+)
+
+// OptionNumber is a CoAP option number.
+type OptionNumber uint16
+
+// CoAP option numbers, as defined in https://tools.ietf.org/html/rfc7252#section-5.10 and
+// https://tools.ietf.org/html/rfc7641#section-2.
+const (
+	IfMatch       OptionNumber = 1
+	URIHost       OptionNumber = 3
+	ETag          OptionNumber = 4
+	IfNoneMatch   OptionNumber = 5
+	Observe       OptionNumber = 6
+	URIPort       OptionNumber = 7
+	LocationPath  OptionNumber = 8
+	URIPath       OptionNumber = 11
+	ContentFormat OptionNumber = 12
+	MaxAge        OptionNumber = 14
+	URIQuery      OptionNumber = 15
+	Accept        OptionNumber = 17
+	LocationQuery OptionNumber = 20
+)
+
+// Option is a CoAP option.
+type Option struct {
+	Number OptionNumber
+	Value  []byte
+}
+
+// Message is a CoAP message.
+type Message struct {
+	Type      Type
+	Code      Code
+	MessageID uint16
+	Token     []byte
+	Options   []Option
+	Payload   []byte
+}
+
+var (
+	// ErrInvalidMessage is returned when decoding a malformed CoAP message.
+	ErrInvalidMessage = errors.New("coap: invalid message")
+	// ErrInvalidToken is returned when encoding a message with a token longer than 8 bytes.
+	ErrInvalidToken = errors.New("coap: token too long")
+)
+
+const (
+	version       = 1
+	payloadMarker = 0xff
+)
+
+// Option returns the value of the first option with number n.
+func (m *Message) Option(n OptionNumber) ([]byte, bool) {
+	for _, o := range m.Options {
+		if o.Number == n {
+			return o.Value, true
+		}
+	}
+	return nil, false
+}
+
+// UintOption returns the value of the first option with number n, decoded as an unsigned integer.
+func (m *Message) UintOption(n OptionNumber) (uint32, bool) {
+	v, ok := m.Option(n)
+	if !ok || len(v) > 4 {
+		return 0, false
+	}
+	var u uint32
+	for _, b := range v {
+		u = u<<8 | uint32(b)
+	}
+	return u, true
+}
+
+// SetOption replaces all options with number n by a single option with value v.
+func (m *Message) SetOption(n OptionNumber, v []byte) {
+	m.RemoveOption(n)
+	m.Options = append(m.Options, Option{Number: n, Value: v})
+}
+
+// SetUintOption replaces all options with number n by a single option with the unsigned integer value v,
+// using the shortest encoding.
+func (m *Message) SetUintOption(n OptionNumber, v uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	i := 0
+	for i < 4 && b[i] == 0 {
+		i++
+	}
+	m.SetOption(n, b[i:])
+}
+
+// RemoveOption removes all options with number n.
+func (m *Message) RemoveOption(n OptionNumber) {
+	opts := m.Options[:0]
+	for _, o := range m.Options {
+		if o.Number != n {
+			opts = append(opts, o)
+		}
+	}
+	m.Options = opts
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler. It encodes the message as defined in
+// https://tools.ietf.org/html/rfc7252#section-3.
+func (m *Message) MarshalBinary() ([]byte, error) {
+	if len(m.Token) > 8 {
+		return nil, ErrInvalidToken
+	}
+	buf := make([]byte, 4, 4+len(m.Token)+len(m.Payload)+16)
+	buf[0] = version<<6 | byte(m.Type&0x3)<<4 | byte(len(m.Token))
+	buf[1] = byte(m.Code)
+	binary.BigEndian.PutUint16(buf[2:], m.MessageID)
+	buf = append(buf, m.Token...)
+
+	opts := make([]Option, len(m.Options))
+	copy(opts, m.Options)
+	sort.SliceStable(opts, func(i, j int) bool { return opts[i].Number < opts[j].Number })
+	var prev OptionNumber
+	for _, o := range opts {
+		delta, dext := optionNibble(uint32(o.Number - prev))
+		length, lext := optionNibble(uint32(len(o.Value)))
+		buf = append(buf, delta<<4|length)
+		buf = append(buf, dext...)
+		buf = append(buf, lext...)
+		buf = append(buf, o.Value...)
+		prev = o.Number
+	}
+
+	if len(m.Payload) > 0 {
+		buf = append(buf, payloadMarker)
+		buf = append(buf, m.Payload...)
+	}
+	return buf, nil
+}
+
+func optionNibble(v uint32) (byte, []byte) {
+	switch {
+	case v < 13:
+		return byte(v), nil
+	case v < 269:
+		return 13, []byte{byte(v - 13)}
+	default:
+		v -= 269
+		return 14, []byte{byte(v >> 8), byte(v)}
+	}
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler. It decodes a CoAP message.
+func (m *Message) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 || data[0]>>6 != version {
+		return ErrInvalidMessage
+	}
+	tkl := int(data[0] & 0xf)
+	if tkl > 8 || len(data) < 4+tkl {
+		return ErrInvalidMessage
+	}
+	*m = Message{
+		Type:      Type(data[0] >> 4 & 0x3),
+		Code:      Code(data[1]),
+		MessageID: binary.BigEndian.Uint16(data[2:]),
+	}
+	if tkl > 0 {
+		m.Token = append([]byte(nil), data[4:4+tkl]...)
+	}
+	data = data[4+tkl:]
+
+	var number uint32
+	for len(data) > 0 {
+		if data[0] == payloadMarker {
+			if len(data) == 1 {
+				return ErrInvalidMessage
+			}
+			m.Payload = append([]byte(nil), data[1:]...)
+			return nil
+		}
+		delta, length := uint32(data[0]>>4), uint32(data[0]&0xf)
+		data = data[1:]
+		var err error
+		if delta, data, err = optionExtended(delta, data); err != nil {
+			return err
+		}
+		if length, data, err = optionExtended(length, data); err != nil {
+			return err
+		}
+		if uint32(len(data)) < length {
+			return ErrInvalidMessage
+		}
+		number += delta
+		if number > 0xffff {
+			return ErrInvalidMessage
+		}
+		m.Options = append(m.Options, Option{Number: OptionNumber(number), Value: append([]byte(nil), data[:length]...)})
+		data = data[length:]
+	}
+	return nil
+}
+
+func optionExtended(v uint32, data []byte) (uint32, []byte, error) {
+	switch v {
+	case 13:
+		if len(data) < 1 {
+			return 0, nil, ErrInvalidMessage
+		}
+		return uint32(data[0]) + 13, data[1:], nil
+	case 14:
+		if len(data) < 2 {
+			return 0, nil, ErrInvalidMessage
+		}
+		return uint32(binary.BigEndian.Uint16(data)) + 269, data[2:], nil
+	case 15:
+		return 0, nil, ErrInvalidMessage
+	}
+	return v, data, nil
+}
@@ -0,0 +1,108 @@
+package coap
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestMessage(t *testing.T) {
+	tcs := []struct {
+		msg Message
+		bin []byte
+	}{
+		{
+			msg: Message{Type: Confirmable, Code: GET, MessageID: 0x1234},
+			bin: []byte{0x40, 0x01, 0x12, 0x34},
+		},
+		{
+			msg: Message{
+				Type:      NonConfirmable,
+				Code:      Content,
+				MessageID: 1,
+				Token:     []byte{0xca, 0xfe},
+				Options: []Option{
+					{Number: ContentFormat, Value: []byte{110}},
+					{Number: Observe, Value: []byte{}},
+				},
+				Payload: []byte("[]"),
+			},
+			bin: []byte{0x52, 0x45, 0x00, 0x01, 0xca, 0xfe, 0x60, 0x61, 110, 0xff, '[', ']'},
+		},
+		{
+			msg: Message{
+				Type: Acknowledgement,
+				Code: Content,
+				Options: []Option{
+					{Number: URIPath, Value: bytes.Repeat([]byte{'a'}, 20)},
+					{Number: 300, Value: []byte{1}},
+				},
+			},
+			bin: append(append([]byte{0x60, 0x45, 0x00, 0x00, 0xbd, 20 - 13}, bytes.Repeat([]byte{'a'}, 20)...), 0xe1, 0x00, 300-11-269, 1),
+		},
+	}
+	for _, tc := range tcs {
+		bin, err := tc.msg.MarshalBinary()
+		if err != nil {
+			t.Errorf("encoding of %+v returned an error : %s", tc.msg, err)
+		}
+		if !bytes.Equal(bin, tc.bin) {
+			t.Errorf("encoding of %+v should be %x not %x", tc.msg, tc.bin, bin)
+		}
+		var dec Message
+		err = dec.UnmarshalBinary(tc.bin)
+		if err != nil {
+			t.Errorf("decoding of %x returned an error : %s", tc.bin, err)
+		}
+		// options are sorted by the encoder
+		bin, _ = dec.MarshalBinary()
+		if !bytes.Equal(bin, tc.bin) || dec.Code != tc.msg.Code || dec.Type != tc.msg.Type || !bytes.Equal(dec.Payload, tc.msg.Payload) {
+			t.Errorf("decoding of %x should be %+v not %+v", tc.bin, tc.msg, dec)
+		}
+	}
+}
+
+func TestInvalidMessage(t *testing.T) {
+	tcs := [][]byte{
+		nil,
+		{0x40, 0x01, 0x00},
+		{0x80, 0x01, 0x00, 0x00},
+		{0x49, 0x01, 0x00, 0x00},
+		{0x42, 0x01, 0x00, 0x00, 0x01},
+		{0x40, 0x01, 0x00, 0x00, 0xff},
+		{0x40, 0x01, 0x00, 0x00, 0xf0},
+		{0x40, 0x01, 0x00, 0x00, 0x13, 0x01},
+		{0x40, 0x01, 0x00, 0x00, 0xd0},
+	}
+	for _, tc := range tcs {
+		var m Message
+		if err := m.UnmarshalBinary(tc); err != ErrInvalidMessage {
+			t.Errorf("decoding of %x should return ErrInvalidMessage, not %v", tc, err)
+		}
+	}
+	m := Message{Token: make([]byte, 9)}
+	if _, err := m.MarshalBinary(); err != ErrInvalidToken {
+		t.Errorf("encoding a 9 bytes token should return ErrInvalidToken, not %v", err)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	m := Message{}
+	for _, v := range []uint32{0, 1, 255, 256, 1 << 24} {
+		m.SetUintOption(Observe, v)
+		if len(m.Options) != 1 {
+			t.Errorf("SetUintOption should replace existing options")
+		}
+		if u, ok := m.UintOption(Observe); !ok || u != v {
+			t.Errorf("UintOption should return %d not %d", v, u)
+		}
+	}
+	m.SetUintOption(Observe, 0)
+	if !reflect.DeepEqual(m.Options, []Option{{Number: Observe, Value: []byte{}}}) {
+		t.Errorf("0 should be encoded as an empty option, not %+v", m.Options)
+	}
+	m.RemoveOption(Observe)
+	if _, ok := m.UintOption(Observe); ok {
+		t.Errorf("RemoveOption should remove the option")
+	}
+}
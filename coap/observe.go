@@ -0,0 +1,152 @@
+package coap
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/objenious/senml"
+)
+
+// ErrStaleNotification is returned when a notification is older than the last one received,
+// as defined in https://tools.ietf.org/html/rfc7641#section-3.4.
+var ErrStaleNotification = errors.New("coap: stale notification")
+
+const (
+	maxObserveSeq       = 1 << 24
+	observeFreshness    = 1 << 23
+	notificationTimeout = 128 * time.Second
+)
+
+// Notifier builds Observe notifications for a SenML resource.
+// Each notification only carries the records that were not sent yet : records without time
+// (i.e. the current values of the resource) are always sent, and records with a time are sent once.
+type Notifier struct {
+	Token  []byte
+	Format senml.Format
+
+	seq uint32
+	// time of the latest record sent, and the records sent with this time
+	last   float64
+	atLast map[string]bool
+	timed  bool
+}
+
+// Notify builds a notification with the records of p that were not sent yet.
+// p is normalized, and relative times are resolved against now, so that records can be compared across notifications :
+// records older than the latest record sent are considered as sent, and records with the same time
+// as the latest record sent are compared with the records sent. Records without time are sent without time.
+// If there are no new records, a nil message is returned.
+// The message ID of the notification is to be set by the caller.
+func (n *Notifier) Notify(p senml.Pack, now time.Time) (*Message, error) {
+	opts := senml.NormalizeOptions{Order: senml.InputOrder, NonFinite: senml.NullNonFinite}
+	untimed, err := p.NormalizeWith(opts)
+	if err != nil {
+		return nil, err
+	}
+	opts.ReferenceTime = func() time.Time { return now }
+	if p, err = p.NormalizeWith(opts); err != nil {
+		return nil, err
+	}
+	for i := range p {
+		if untimed[i].Time == 0 {
+			p[i].Time = 0
+		}
+	}
+	sort.Stable(p)
+	inc := make(senml.Pack, 0, len(p))
+	keys := make([]string, 0, len(p))
+	for i := range p {
+		r := &p[i]
+		var key string
+		if r.Time != 0 && n.timed {
+			if r.Time < n.last {
+				continue
+			}
+			if r.Time == n.last {
+				b, err := r.AppendJSON(nil)
+				if err != nil {
+					return nil, err
+				}
+				if key = string(b); n.atLast[key] {
+					continue
+				}
+			}
+		}
+		inc = append(inc, *r)
+		keys = append(keys, key)
+	}
+	if len(inc) == 0 {
+		return nil, nil
+	}
+	m := &Message{
+		Type:  NonConfirmable,
+		Code:  Content,
+		Token: n.Token,
+	}
+	m.SetUintOption(Observe, n.seq)
+	if err := SetPack(m, n.Format, inc); err != nil {
+		return nil, err
+	}
+	n.seq = (n.seq + 1) % maxObserveSeq
+	for i := range inc {
+		r := &inc[i]
+		if r.Time == 0 {
+			continue
+		}
+		if !n.timed || r.Time > n.last {
+			n.timed = true
+			n.last = r.Time
+			n.atLast = map[string]bool{}
+		}
+		if r.Time == n.last {
+			if keys[i] == "" {
+				b, err := r.AppendJSON(nil)
+				if err != nil {
+					return nil, err
+				}
+				keys[i] = string(b)
+			}
+			n.atLast[keys[i]] = true
+		}
+	}
+	return m, nil
+}
+
+// Observer accumulates the incremental packs received in Observe notifications.
+type Observer struct {
+	// Pack holds all records received so far.
+	Pack senml.Pack
+
+	seq      uint32
+	received time.Time
+}
+
+// Handle decodes a notification, and appends its records to o.Pack.
+// The decoded (normalized) records are returned.
+// ErrStaleNotification is returned if the notification was reordered and is older than the last one handled.
+func (o *Observer) Handle(m *Message) (senml.Pack, error) {
+	seq, ok := m.UintOption(Observe)
+	now := time.Now()
+	if ok && !o.received.IsZero() && !fresh(o.seq, seq, o.received, now) {
+		return nil, ErrStaleNotification
+	}
+	p, err := Pack(m)
+	if err != nil {
+		return nil, err
+	}
+	p = p.Normalize()
+	if ok {
+		o.seq = seq
+		o.received = now
+	}
+	o.Pack = append(o.Pack, p...)
+	return p, nil
+}
+
+// fresh checks if the notification v2 received at t2 is newer than v1 received at t1.
+func fresh(v1, v2 uint32, t1, t2 time.Time) bool {
+	return (v1 < v2 && v2-v1 < observeFreshness) ||
+		(v1 > v2 && v1-v2 > observeFreshness) ||
+		t2.After(t1.Add(notificationTimeout))
+}
@@ -0,0 +1,92 @@
+// Package coap encodes and decodes SenML packs as CoAP message payloads,
+// as described in https://tools.ietf.org/html/rfc8428#section-12.3.
+package coap
+
+import (
+	"errors"
+	"math"
+
+	"github.com/objenious/senml"
+)
+
+// ErrNoContentFormat is returned when decoding the payload of a message without a Content-Format option.
+var ErrNoContentFormat = errors.New("coap: no content format")
+
+// SetPack encodes p in the format f, and sets it as the payload of m, with the matching Content-Format option.
+func SetPack(m *Message, f senml.Format, p senml.Pack) error {
+	cf := f.ContentFormat()
+	if cf == 0 {
+		return senml.ErrUnsupportedFormat
+	}
+	b, err := senml.Marshal(f, p)
+	if err != nil {
+		return err
+	}
+	m.SetUintOption(ContentFormat, uint32(cf))
+	m.Payload = b
+	return nil
+}
+
+// Pack decodes the payload of m, according to its Content-Format option.
+func Pack(m *Message) (senml.Pack, error) {
+	cf, ok := m.UintOption(ContentFormat)
+	if !ok {
+		return nil, ErrNoContentFormat
+	}
+	if cf > math.MaxUint16 {
+		return nil, senml.ErrUnsupportedFormat
+	}
+	f, ok := senml.FormatFromContentFormat(uint16(cf))
+	if !ok {
+		return nil, senml.ErrUnsupportedFormat
+	}
+	return senml.Unmarshal(f, m.Payload)
+}
+
+// AcceptFormat returns the SenML format requested by the Accept option of m.
+// If m has no Accept option, def is returned.
+// ok is false if the requested content format is not a supported SenML format.
+func AcceptFormat(m *Message, def senml.Format) (f senml.Format, ok bool) {
+	cf, found := m.UintOption(Accept)
+	if !found {
+		return def, true
+	}
+	if cf > math.MaxUint16 {
+		return senml.UnknownFormat, false
+	}
+	f, ok = senml.FormatFromContentFormat(uint16(cf))
+	if !ok {
+		return senml.UnknownFormat, false
+	}
+	// check that the format is implemented by the senml package
+	if _, err := senml.Marshal(f, nil); err == senml.ErrUnsupportedFormat {
+		return senml.UnknownFormat, false
+	}
+	return f, true
+}
+
+// Response builds a piggybacked response to req, with p encoded in the format requested by its Accept option
+// (or def if there is none). A 4.06 Not Acceptable response is returned if the requested format is not supported.
+// The response to a non-confirmable request is non-confirmable : its message ID is to be set by the caller,
+// as defined in https://tools.ietf.org/html/rfc7252#section-5.2.3.
+func Response(req *Message, def senml.Format, p senml.Pack) (*Message, error) {
+	resp := &Message{
+		Type:      Acknowledgement,
+		Code:      Content,
+		MessageID: req.MessageID,
+		Token:     req.Token,
+	}
+	if req.Type != Confirmable {
+		resp.Type = NonConfirmable
+		resp.MessageID = 0
+	}
+	f, ok := AcceptFormat(req, def)
+	if !ok {
+		resp.Code = NotAcceptable
+		return resp, nil
+	}
+	if err := SetPack(resp, f, p); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
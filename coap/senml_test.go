@@ -0,0 +1,305 @@
+package coap
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/objenious/senml"
+)
+
+// server is an in-process CoAP server, serving a SenML resource over an UDP loopback socket.
+// GET requests get the resource in the format requested by the Accept option,
+// and GET requests with an Observe option receive a notification for each pack sent on updates.
+func server(t *testing.T, res senml.Pack, updates <-chan senml.Pack) net.Addr {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen : %s", err)
+	}
+	go func() {
+		defer conn.Close()
+		buf := make([]byte, 1500)
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		req := &Message{}
+		if err := req.UnmarshalBinary(buf[:n]); err != nil {
+			return
+		}
+		send := func(m *Message) {
+			b, err := m.MarshalBinary()
+			if err == nil {
+				_, _ = conn.WriteTo(b, addr)
+			}
+		}
+		if _, ok := req.UintOption(Observe); !ok {
+			resp, err := Response(req, senml.JSON, res)
+			if err == nil {
+				send(resp)
+			}
+			return
+		}
+		f, _ := AcceptFormat(req, senml.JSON)
+		nt := &Notifier{Token: req.Token, Format: f}
+		mid := req.MessageID
+		for p := range updates {
+			res = append(res, p...)
+			m, err := nt.Notify(res, time.Now())
+			if err != nil || m == nil {
+				continue
+			}
+			m.MessageID = mid
+			mid++
+			send(m)
+		}
+	}()
+	return conn.LocalAddr()
+}
+
+func roundTrip(t *testing.T, conn net.Conn, req *Message) *Message {
+	b, err := req.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unable to encode request : %s", err)
+	}
+	if _, err := conn.Write(b); err != nil {
+		t.Fatalf("unable to send request : %s", err)
+	}
+	return receive(t, conn)
+}
+
+func receive(t *testing.T, conn net.Conn) *Message {
+	buf := make([]byte, 1500)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("unable to receive message : %s", err)
+	}
+	m := &Message{}
+	if err := m.UnmarshalBinary(buf[:n]); err != nil {
+		t.Fatalf("unable to decode message : %s", err)
+	}
+	return m
+}
+
+func TestGet(t *testing.T) {
+	res := senml.Pack{
+		{BaseName: "urn:dev:ow:10e2073a01080063:", Name: "temp", Unit: senml.Celsius, Value: senml.Float(23.1)},
+	}
+	tcs := []struct {
+		accept uint32
+		format senml.Format
+		code   Code
+	}{
+		{accept: 0, format: senml.JSON, code: Content},
+		{accept: 110, format: senml.JSON, code: Content},
+		{accept: 310, format: senml.XML, code: Content},
+		{accept: 112, code: NotAcceptable},
+		{accept: 50, code: NotAcceptable},
+	}
+	for _, tc := range tcs {
+		conn, err := net.Dial("udp", server(t, res, nil).String())
+		if err != nil {
+			t.Fatalf("unable to connect : %s", err)
+		}
+		req := &Message{Type: Confirmable, Code: GET, MessageID: 42, Token: []byte{1, 2, 3}}
+		if tc.accept != 0 {
+			req.SetUintOption(Accept, tc.accept)
+		}
+		resp := roundTrip(t, conn, req)
+		conn.Close()
+		if resp.Type != Acknowledgement || resp.MessageID != 42 || string(resp.Token) != string(req.Token) {
+			t.Errorf("response %+v does not match request %+v", resp, req)
+		}
+		if resp.Code != tc.code {
+			t.Errorf("response code for accept %d should be %x not %x", tc.accept, tc.code, resp.Code)
+			continue
+		}
+		if tc.code != Content {
+			continue
+		}
+		if cf, _ := resp.UintOption(ContentFormat); cf != uint32(tc.format.ContentFormat()) {
+			t.Errorf("content format for accept %d should be %d not %d", tc.accept, tc.format.ContentFormat(), cf)
+		}
+		p, err := Pack(resp)
+		if err != nil {
+			t.Errorf("decoding of %s returned an error : %s", resp.Payload, err)
+		}
+		if !res.Equals(p) {
+			t.Errorf("decoding of %s should be %+v not %+v", resp.Payload, res, p)
+		}
+	}
+}
+
+func TestObserve(t *testing.T) {
+	res := senml.Pack{
+		{BaseName: "urn:dev:ow:10e2073a01080063:", BaseTime: 1.320067464e+09, Name: "temp", Unit: senml.Celsius, Value: senml.Float(23.1)},
+	}
+	updates := make(chan senml.Pack, 3)
+	updates <- nil
+	updates <- senml.Pack{{BaseName: "urn:dev:ow:10e2073a01080063:", BaseTime: 1.320067524e+09, Name: "temp", Unit: senml.Celsius, Value: senml.Float(23.4)}}
+	updates <- senml.Pack{
+		{BaseName: "urn:dev:ow:10e2073a01080063:", BaseTime: 1.320067584e+09, Name: "temp", Unit: senml.Celsius, Value: senml.Float(23.5)},
+		{Name: "hum", Unit: senml.RelativeHumidity, Value: senml.Float(40)},
+	}
+	close(updates)
+	conn, err := net.Dial("udp", server(t, res, updates).String())
+	if err != nil {
+		t.Fatalf("unable to connect : %s", err)
+	}
+	defer conn.Close()
+	req := &Message{Type: NonConfirmable, Code: GET, MessageID: 1, Token: []byte{0xab}}
+	req.SetUintOption(Observe, 0)
+	req.SetUintOption(Accept, uint32(senml.ContentFormatXML))
+
+	o := &Observer{}
+	m := roundTrip(t, conn, req)
+	for i, l := range []int{1, 1, 2} {
+		if i > 0 {
+			m = receive(t, conn)
+		}
+		if seq, _ := m.UintOption(Observe); seq != uint32(i) {
+			t.Errorf("notification %d should have sequence number %d not %d", i, i, seq)
+		}
+		p, err := o.Handle(m)
+		if err != nil {
+			t.Fatalf("handling notification %d returned an error : %s", i, err)
+		}
+		if len(p) != l {
+			t.Errorf("notification %d should contain %d records not %d", i, l, len(p))
+		}
+	}
+	exp := senml.Pack{
+		{Name: "urn:dev:ow:10e2073a01080063:temp", Time: 1.320067464e+09, Unit: senml.Celsius, Value: senml.Float(23.1)},
+		{Name: "urn:dev:ow:10e2073a01080063:temp", Time: 1.320067524e+09, Unit: senml.Celsius, Value: senml.Float(23.4)},
+		{Name: "urn:dev:ow:10e2073a01080063:temp", Time: 1.320067584e+09, Unit: senml.Celsius, Value: senml.Float(23.5)},
+		{Name: "urn:dev:ow:10e2073a01080063:hum", Time: 1.320067584e+09, Unit: senml.RelativeHumidity, Value: senml.Float(40)},
+	}
+	if !exp.Equals(o.Pack) {
+		t.Errorf("observed pack should be %+v not %+v", exp, o.Pack)
+	}
+
+	// a reordered notification is rejected
+	stale := &Message{}
+	stale.SetUintOption(Observe, 1)
+	if _, err := o.Handle(stale); err != ErrStaleNotification {
+		t.Errorf("handling a reordered notification should return ErrStaleNotification, not %v", err)
+	}
+}
+
+func TestFresh(t *testing.T) {
+	t0 := time.Now()
+	tcs := []struct {
+		v1, v2 uint32
+		t2     time.Time
+		fresh  bool
+	}{
+		{v1: 1, v2: 2, t2: t0, fresh: true},
+		{v1: 2, v2: 1, t2: t0, fresh: false},
+		{v1: 1, v2: 1, t2: t0, fresh: false},
+		{v1: 1<<24 - 1, v2: 0, t2: t0, fresh: true},
+		{v1: 0, v2: 1<<24 - 1, t2: t0, fresh: false},
+		{v1: 2, v2: 1, t2: t0.Add(129 * time.Second), fresh: true},
+	}
+	for _, tc := range tcs {
+		if fresh(tc.v1, tc.v2, t0, tc.t2) != tc.fresh {
+			t.Errorf("fresh(%d, %d) should return %v", tc.v1, tc.v2, tc.fresh)
+		}
+	}
+}
+
+func TestPackErrors(t *testing.T) {
+	if _, err := Pack(&Message{Payload: []byte("[]")}); err != ErrNoContentFormat {
+		t.Errorf("decoding a message without content format should return ErrNoContentFormat, not %v", err)
+	}
+	m := &Message{Payload: []byte("[]")}
+	m.SetUintOption(ContentFormat, 50)
+	if _, err := Pack(m); err != senml.ErrUnsupportedFormat {
+		t.Errorf("decoding a message with an unknown content format should return ErrUnsupportedFormat, not %v", err)
+	}
+	if err := SetPack(m, senml.CBOR, nil); err != senml.ErrUnsupportedFormat {
+		t.Errorf("encoding to CBOR should return ErrUnsupportedFormat, not %v", err)
+	}
+	// 65536 + 110 would be SenML JSON if truncated to 16 bits
+	m.SetUintOption(ContentFormat, 1<<16+uint32(senml.ContentFormatJSON))
+	if _, err := Pack(m); err != senml.ErrUnsupportedFormat {
+		t.Errorf("decoding a message with an out of range content format should return ErrUnsupportedFormat, not %v", err)
+	}
+	m.SetUintOption(Accept, 1<<16+uint32(senml.ContentFormatJSON))
+	if _, ok := AcceptFormat(m, senml.JSON); ok {
+		t.Errorf("an out of range accept option should not be supported")
+	}
+}
+
+func TestNotify(t *testing.T) {
+	nt := &Notifier{Format: senml.JSON}
+	now := time.Unix(1320067464, 0)
+	notify := func(p senml.Pack) senml.Pack {
+		m, err := nt.Notify(p, now)
+		if err != nil {
+			t.Fatalf("Notify returned an error : %s", err)
+		}
+		if m == nil {
+			return nil
+		}
+		dec, err := Pack(m)
+		if err != nil {
+			t.Fatalf("decoding of %s returned an error : %s", m.Payload, err)
+		}
+		return dec
+	}
+
+	// records without time are the current values of the resource
+	for _, v := range []float64{20, 21, 21} {
+		p := senml.Pack{{Name: "temp", Value: senml.Float(v)}}
+		if dec := notify(p); !dec.Equals(p) {
+			t.Errorf("notification of %+v should be %+v not %+v", p, p, dec)
+		}
+	}
+
+	// records with the same time as the latest record sent
+	nt = &Notifier{Format: senml.JSON}
+	p := senml.Pack{{Name: "temp", Time: 1.320067564e+09, Value: senml.Float(20)}}
+	if dec := notify(p); !dec.Equals(p) {
+		t.Errorf("notification of %+v should be %+v not %+v", p, p, dec)
+	}
+	p = append(p, senml.Record{Name: "hum", Time: 1.320067564e+09, Value: senml.Float(40)})
+	if dec := notify(p); !dec.Equals(p[1:]) {
+		t.Errorf("notification of %+v should be %+v not %+v", p, p[1:], dec)
+	}
+	p = append(p, senml.Record{Name: "temp", Time: 1.320067514e+09, Value: senml.Float(19)})
+	if dec := notify(p); dec != nil {
+		t.Errorf("notification of records already sent should be nil not %+v", dec)
+	}
+	p = append(p, senml.Record{Name: "temp", Time: 1.320067624e+09, Value: senml.Float(21)}, senml.Record{Name: "open", BoolValue: senml.True})
+	if dec, exp := notify(p), (senml.Pack{{Name: "open", BoolValue: senml.True}, {Name: "temp", Time: 1.320067624e+09, Value: senml.Float(21)}}); !dec.Equals(exp) {
+		t.Errorf("notification of %+v should be %+v not %+v", p, exp, dec)
+	}
+
+	// relative times are resolved against the time of the notification
+	nt = &Notifier{Format: senml.JSON}
+	p = senml.Pack{{BaseTime: -60, Name: "temp", Value: senml.Float(20)}}
+	if dec, exp := notify(p), (senml.Pack{{Name: "temp", Time: 1.320067404e+09, Value: senml.Float(20)}}); !dec.Equals(exp) {
+		t.Errorf("notification of %+v should be %+v not %+v", p, exp, dec)
+	}
+	now = now.Add(time.Minute)
+	p = senml.Pack{{BaseTime: -30, Name: "temp", Value: senml.Float(21)}}
+	if dec, exp := notify(p), (senml.Pack{{Name: "temp", Time: 1.320067494e+09, Value: senml.Float(21)}}); !dec.Equals(exp) {
+		t.Errorf("notification of %+v should be %+v not %+v", p, exp, dec)
+	}
+	p = senml.Pack{{BaseTime: -90, Name: "temp", Value: senml.Float(21)}}
+	if dec := notify(p); dec != nil {
+		t.Errorf("notification of records already sent should be nil not %+v", dec)
+	}
+}
+
+func TestResponseNonConfirmable(t *testing.T) {
+	req := &Message{Type: NonConfirmable, Code: GET, MessageID: 42, Token: []byte{1}}
+	resp, err := Response(req, senml.JSON, senml.Pack{{Name: "temp", Value: senml.Float(20)}})
+	if err != nil {
+		t.Fatalf("Response returned an error : %s", err)
+	}
+	if resp.Type != NonConfirmable || resp.MessageID != 0 || string(resp.Token) != string(req.Token) {
+		t.Errorf("response to a non-confirmable request should be non-confirmable, without message ID, not %+v", resp)
+	}
+}
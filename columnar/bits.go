@@ -0,0 +1,264 @@
+package columnar
+
+import (
+	"math"
+	"math/bits"
+)
+
+// bitWriter writes a stream of bits, most significant bit first.
+type bitWriter struct {
+	buf   []byte
+	count uint8 // number of bits used in the last byte
+}
+
+func (w *bitWriter) writeBit(b bool) {
+	if w.count == 0 || w.count == 8 {
+		w.buf = append(w.buf, 0)
+		w.count = 0
+	}
+	if b {
+		w.buf[len(w.buf)-1] |= 0x80 >> w.count
+	}
+	w.count++
+}
+
+// writeBits writes the n least significant bits of v.
+func (w *bitWriter) writeBits(v uint64, n int) {
+	for i := n - 1; i >= 0; i-- {
+		w.writeBit(v>>uint(i)&1 == 1)
+	}
+}
+
+// bitReader reads a stream of bits written by a bitWriter.
+type bitReader struct {
+	buf []byte
+	pos int // position in bits
+}
+
+func (r *bitReader) readBit() (bool, error) {
+	if r.pos >= len(r.buf)*8 {
+		return false, ErrInvalidData
+	}
+	b := r.buf[r.pos/8]&(0x80>>uint(r.pos%8)) != 0
+	r.pos++
+	return b, nil
+}
+
+func (r *bitReader) readBits(n int) (uint64, error) {
+	var v uint64
+	for i := 0; i < n; i++ {
+		b, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		v <<= 1
+		if b {
+			v |= 1
+		}
+	}
+	return v, nil
+}
+
+// dodBuckets are the bit sizes used to store delta-of-deltas, after a prefix of 1 bits terminated by a 0 bit.
+// The last bucket is not terminated by a 0 bit.
+var dodBuckets = []int{7, 9, 12, 64}
+
+// timeEncoder compresses timestamps with delta-of-delta encoding, as described in
+// "Gorilla: A Fast, Scalable, In-Memory Time Series Database" (section 4.1.1).
+type timeEncoder struct {
+	w     bitWriter
+	n     int
+	prev  int64
+	delta int64
+}
+
+func (e *timeEncoder) write(t int64) {
+	switch e.n {
+	case 0:
+		e.w.writeBits(uint64(t), 64)
+	case 1:
+		e.delta = t - e.prev
+		e.w.writeBits(uint64(e.delta), 64)
+	default:
+		delta := t - e.prev
+		dod := delta - e.delta
+		e.delta = delta
+		if dod == 0 {
+			e.w.writeBit(false)
+			break
+		}
+		for i, size := range dodBuckets {
+			e.w.writeBit(true)
+			if i == len(dodBuckets)-1 {
+				e.w.writeBits(uint64(dod), size)
+				break
+			}
+			if min, max := -int64(1)<<uint(size-1), int64(1)<<uint(size-1)-1; dod >= min && dod <= max {
+				e.w.writeBit(false)
+				e.w.writeBits(uint64(dod), size)
+				break
+			}
+		}
+	}
+	e.prev = t
+	e.n++
+}
+
+type timeDecoder struct {
+	r     bitReader
+	n     int
+	prev  int64
+	delta int64
+}
+
+func (d *timeDecoder) read() (int64, error) {
+	var t int64
+	switch d.n {
+	case 0:
+		v, err := d.r.readBits(64)
+		if err != nil {
+			return 0, err
+		}
+		t = int64(v)
+	case 1:
+		v, err := d.r.readBits(64)
+		if err != nil {
+			return 0, err
+		}
+		d.delta = int64(v)
+		t = d.prev + d.delta
+	default:
+		var dod int64
+		b, err := d.r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		if b {
+			i := 0
+			for i < len(dodBuckets)-1 {
+				if b, err = d.r.readBit(); err != nil {
+					return 0, err
+				}
+				if !b {
+					break
+				}
+				i++
+			}
+			size := dodBuckets[i]
+			v, err := d.r.readBits(size)
+			if err != nil {
+				return 0, err
+			}
+			// sign extension
+			dod = int64(v<<uint(64-size)) >> uint(64-size)
+		}
+		d.delta += dod
+		t = d.prev + d.delta
+	}
+	d.prev = t
+	d.n++
+	return t, nil
+}
+
+// floatEncoder compresses floating point values by XORing them with the previous value, as described in
+// "Gorilla: A Fast, Scalable, In-Memory Time Series Database" (section 4.1.2).
+type floatEncoder struct {
+	w        bitWriter
+	n        int
+	prev     uint64
+	leading  int
+	trailing int
+}
+
+func (e *floatEncoder) write(f float64) {
+	v := math.Float64bits(f)
+	if e.n == 0 {
+		e.w.writeBits(v, 64)
+		e.prev = v
+		// no window yet, the first changed value writes a new one
+		e.leading = 64
+		e.n++
+		return
+	}
+	xor := v ^ e.prev
+	e.prev = v
+	if xor == 0 {
+		e.w.writeBit(false)
+		return
+	}
+	e.w.writeBit(true)
+	leading, trailing := bits.LeadingZeros64(xor), bits.TrailingZeros64(xor)
+	if leading > 31 {
+		leading = 31
+	}
+	if leading >= e.leading && trailing >= e.trailing {
+		// the meaningful bits fit in the previous window
+		e.w.writeBit(false)
+		e.w.writeBits(xor>>uint(e.trailing), 64-e.leading-e.trailing)
+		return
+	}
+	e.leading, e.trailing = leading, trailing
+	size := 64 - leading - trailing
+	e.w.writeBit(true)
+	e.w.writeBits(uint64(leading), 5)
+	// a size of 64 is stored as 0
+	e.w.writeBits(uint64(size&63), 6)
+	e.w.writeBits(xor>>uint(trailing), size)
+}
+
+type floatDecoder struct {
+	r        bitReader
+	n        int
+	prev     uint64
+	leading  int
+	trailing int
+}
+
+func (d *floatDecoder) read() (float64, error) {
+	if d.n == 0 {
+		v, err := d.r.readBits(64)
+		if err != nil {
+			return 0, err
+		}
+		d.prev = v
+		d.leading = 64
+		d.n++
+		return math.Float64frombits(v), nil
+	}
+	b, err := d.r.readBit()
+	if err != nil {
+		return 0, err
+	}
+	if !b {
+		return math.Float64frombits(d.prev), nil
+	}
+	if b, err = d.r.readBit(); err != nil {
+		return 0, err
+	}
+	if b {
+		leading, err := d.r.readBits(5)
+		if err != nil {
+			return 0, err
+		}
+		size, err := d.r.readBits(6)
+		if err != nil {
+			return 0, err
+		}
+		if size == 0 {
+			size = 64
+		}
+		if int(leading)+int(size) > 64 {
+			return 0, ErrInvalidData
+		}
+		d.leading, d.trailing = int(leading), 64-int(leading)-int(size)
+	} else if d.leading == 64 {
+		// reuse of the window before any window was written
+		return 0, ErrInvalidData
+	}
+	v, err := d.r.readBits(64 - d.leading - d.trailing)
+	if err != nil {
+		return 0, err
+	}
+	d.prev ^= v << uint(d.trailing)
+	return math.Float64frombits(d.prev), nil
+}
@@ -0,0 +1,379 @@
+// Package columnar implements a compact binary encoding of normalized SenML packs, for archival.
+//
+// Records are stored column by column : names and units as indexes in a string dictionary,
+// times with delta-of-delta encoding, and numeric values XORed with the previous value,
+// as described in "Gorilla: A Fast, Scalable, In-Memory Time Series Database" (http://www.vldb.org/pvldb/vol8/p1816-teller.pdf).
+// Decoding returns exactly the encoded pack.
+package columnar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/objenious/senml"
+)
+
+var (
+	// ErrNotNormalized is returned when encoding a pack with base fields, update times or several values in a record.
+	ErrNotNormalized = errors.New("columnar: pack is not normalized")
+	// ErrInvalidData is returned when decoding malformed data.
+	ErrInvalidData = errors.New("columnar: invalid data")
+)
+
+var magic = []byte("SMLC")
+
+const version = 1
+
+// value kinds
+const (
+	kindNone byte = iota
+	kindValue
+	kindSum
+	kindBool
+	kindString
+	kindData
+)
+
+// time encodings
+const (
+	// times are stored as multiples of a power of ten of nanoseconds, with delta-of-delta encoding
+	timeNanoseconds byte = iota
+	// times are stored as SenML times, XORed with the previous time
+	timeFloat
+)
+
+// Marshal encodes a normalized pack.
+func Marshal(p senml.Pack) ([]byte, error) {
+	kinds := make([]byte, len(p))
+	for i := range p {
+		r := &p[i]
+		if r.BaseName != "" || r.BaseTime != 0 || r.BaseUnit != "" || r.BaseValue != nil || r.BaseSum != nil || r.UpdateTime != 0 {
+			return nil, ErrNotNormalized
+		}
+		n := 0
+		if r.Value != nil {
+			kinds[i] = kindValue
+			n++
+		}
+		if r.Sum != nil {
+			kinds[i] = kindSum
+			n++
+		}
+		if r.BoolValue != nil {
+			kinds[i] = kindBool
+			n++
+		}
+		if r.StringValue != "" {
+			kinds[i] = kindString
+			n++
+		}
+		if r.DataValue != nil {
+			kinds[i] = kindData
+			n++
+		}
+		if n > 1 {
+			return nil, ErrNotNormalized
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.Write(magic)
+	buf.WriteByte(version)
+	putUvarint(&buf, uint64(len(p)))
+
+	// string dictionary
+	dict := map[string]uint64{}
+	var strs []string
+	index := func(s string) uint64 {
+		i, found := dict[s]
+		if !found {
+			i = uint64(len(strs))
+			dict[s] = i
+			strs = append(strs, s)
+		}
+		return i
+	}
+	var names, units bytes.Buffer
+	for i := range p {
+		putUvarint(&names, index(p[i].Name))
+		putUvarint(&units, index(string(p[i].Unit)))
+	}
+	putUvarint(&buf, uint64(len(strs)))
+	for _, s := range strs {
+		putBytes(&buf, []byte(s))
+	}
+	buf.Write(names.Bytes())
+	buf.Write(units.Bytes())
+
+	// run length encoded versions and kinds
+	runs(&buf, len(p), func(i int) uint64 { return uint64(int64(p[i].BaseVersion)) })
+	runs(&buf, len(p), func(i int) uint64 { return uint64(kinds[i]) })
+
+	// times
+	exact := true
+	for i := range p {
+		if _, ok := nanoseconds(p[i].Time); !ok {
+			exact = false
+			break
+		}
+	}
+	if exact {
+		// use the largest unit in which all times are integers, so that regular deltas stay small
+		ns := make([]int64, len(p))
+		for i := range p {
+			ns[i], _ = nanoseconds(p[i].Time)
+		}
+		scale, unit := 0, int64(1)
+		for ; scale < 9; scale, unit = scale+1, unit*10 {
+			divisible := true
+			for _, t := range ns {
+				if t%(unit*10) != 0 {
+					divisible = false
+					break
+				}
+			}
+			if !divisible {
+				break
+			}
+		}
+		buf.WriteByte(timeNanoseconds)
+		buf.WriteByte(byte(scale))
+		e := timeEncoder{}
+		for _, t := range ns {
+			e.write(t / unit)
+		}
+		putBytes(&buf, e.w.buf)
+	} else {
+		buf.WriteByte(timeFloat)
+		e := floatEncoder{}
+		for i := range p {
+			e.write(p[i].Time)
+		}
+		putBytes(&buf, e.w.buf)
+	}
+
+	// values
+	floats := floatEncoder{}
+	bools := bitWriter{}
+	var stringValues, dataValues bytes.Buffer
+	for i := range p {
+		switch kinds[i] {
+		case kindValue:
+			floats.write(*p[i].Value)
+		case kindSum:
+			floats.write(*p[i].Sum)
+		case kindBool:
+			bools.writeBit(*p[i].BoolValue)
+		case kindString:
+			putBytes(&stringValues, []byte(p[i].StringValue))
+		case kindData:
+			putBytes(&dataValues, p[i].DataValue)
+		}
+	}
+	putBytes(&buf, floats.w.buf)
+	putBytes(&buf, bools.buf)
+	buf.Write(stringValues.Bytes())
+	buf.Write(dataValues.Bytes())
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes a pack encoded by Marshal.
+func Unmarshal(b []byte) (senml.Pack, error) {
+	if len(b) < len(magic)+1 || !bytes.Equal(b[:len(magic)], magic) || b[len(magic)] != version {
+		return nil, ErrInvalidData
+	}
+	r := &reader{buf: b[len(magic)+1:]}
+	n := r.count()
+	p := make(senml.Pack, n)
+
+	strs := make([]string, r.count())
+	for i := range strs {
+		strs[i] = string(r.readBytes())
+	}
+	str := func() string {
+		i := r.uvarint()
+		if i >= uint64(len(strs)) {
+			r.err = ErrInvalidData
+			return ""
+		}
+		return strs[i]
+	}
+	for i := range p {
+		p[i].Name = str()
+	}
+	for i := range p {
+		p[i].Unit = senml.Unit(str())
+	}
+	r.runs(n, func(i int, v uint64) { p[i].BaseVersion = int(int64(v)) })
+	kinds := make([]byte, n)
+	r.runs(n, func(i int, v uint64) { kinds[i] = byte(v) })
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	switch r.readByte() {
+	case timeNanoseconds:
+		scale := r.readByte()
+		if scale > 9 {
+			r.fail(ErrInvalidData)
+		}
+		unit := int64(math.Pow10(int(scale)))
+		d := timeDecoder{r: bitReader{buf: r.readBytes()}}
+		for i := 0; i < n && r.err == nil; i++ {
+			t, err := d.read()
+			r.fail(err)
+			p[i].Time = float64(t*unit) / 1e9
+		}
+	case timeFloat:
+		d := floatDecoder{r: bitReader{buf: r.readBytes()}}
+		for i := 0; i < n && r.err == nil; i++ {
+			t, err := d.read()
+			r.fail(err)
+			p[i].Time = t
+		}
+	default:
+		r.fail(ErrInvalidData)
+	}
+
+	floats := floatDecoder{r: bitReader{buf: r.readBytes()}}
+	bools := bitReader{buf: r.readBytes()}
+	for i := 0; i < n && r.err == nil; i++ {
+		switch kinds[i] {
+		case kindNone, kindData:
+		case kindValue, kindSum:
+			f, err := floats.read()
+			r.fail(err)
+			if kinds[i] == kindValue {
+				p[i].Value = &f
+			} else {
+				p[i].Sum = &f
+			}
+		case kindBool:
+			b, err := bools.readBit()
+			r.fail(err)
+			p[i].BoolValue = &b
+		case kindString:
+			p[i].StringValue = string(r.readBytes())
+		default:
+			r.fail(ErrInvalidData)
+		}
+	}
+	for i := 0; i < n && r.err == nil; i++ {
+		if kinds[i] == kindData {
+			p[i].DataValue = r.readBytes()
+		}
+	}
+	if r.err == nil && len(r.buf) != 0 {
+		r.err = ErrInvalidData
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return p, nil
+}
+
+// nanoseconds converts a SenML time to nanoseconds, if the conversion is exact.
+func nanoseconds(t float64) (int64, bool) {
+	ns := math.Floor(t*1e9 + 0.5)
+	if math.Abs(ns) >= 1<<63 || float64(int64(ns))/1e9 != t {
+		return 0, false
+	}
+	return int64(ns), true
+}
+
+func putUvarint(buf *bytes.Buffer, v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+func putBytes(buf *bytes.Buffer, b []byte) {
+	putUvarint(buf, uint64(len(b)))
+	buf.Write(b)
+}
+
+// runs writes n values as (value, run length) pairs.
+func runs(buf *bytes.Buffer, n int, value func(i int) uint64) {
+	for i := 0; i < n; {
+		v := value(i)
+		j := i + 1
+		for j < n && value(j) == v {
+			j++
+		}
+		putUvarint(buf, v)
+		putUvarint(buf, uint64(j-i))
+		i = j
+	}
+}
+
+// reader reads the encoded data, and keeps the first error.
+type reader struct {
+	buf []byte
+	err error
+}
+
+func (r *reader) fail(err error) {
+	if r.err == nil && err != nil {
+		r.err = err
+	}
+}
+
+func (r *reader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.fail(ErrInvalidData)
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+// count reads a number of items, each taking at least a byte.
+func (r *reader) count() int {
+	v := r.uvarint()
+	if v > uint64(len(r.buf)) {
+		r.fail(ErrInvalidData)
+		return 0
+	}
+	return int(v)
+}
+
+func (r *reader) readByte() byte {
+	if r.err != nil || len(r.buf) == 0 {
+		r.fail(ErrInvalidData)
+		return 0
+	}
+	b := r.buf[0]
+	r.buf = r.buf[1:]
+	return b
+}
+
+func (r *reader) readBytes() []byte {
+	l := r.count()
+	if r.err != nil {
+		return nil
+	}
+	b := make([]byte, l)
+	copy(b, r.buf)
+	r.buf = r.buf[l:]
+	return b
+}
+
+func (r *reader) runs(n int, set func(i int, v uint64)) {
+	for i := 0; i < n && r.err == nil; {
+		v := r.uvarint()
+		l := r.uvarint()
+		if l == 0 || l > uint64(n-i) {
+			r.fail(ErrInvalidData)
+			return
+		}
+		for j := 0; j < int(l); j++ {
+			set(i+j, v)
+		}
+		i += int(l)
+	}
+}
@@ -0,0 +1,144 @@
+package columnar
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/objenious/senml"
+)
+
+func TestMarshal(t *testing.T) {
+	tcs := []senml.Pack{
+		{},
+		{
+			{Name: "urn:dev:ow:10e2073a01080063:temp", Unit: senml.Celsius, Time: 1.320067464e+09, Value: senml.Float(23.1)},
+		},
+		{
+			{Name: "urn:dev:ow:10e2073a01080063:temp", Unit: senml.Celsius, Time: 1.320067464e+09, Value: senml.Float(23.1), BaseVersion: 10},
+			{Name: "urn:dev:ow:10e2073a01080063:temp", Unit: senml.Celsius, Time: 1.320067524e+09, Value: senml.Float(23.1), BaseVersion: 10},
+			{Name: "urn:dev:ow:10e2073a01080063:energy", Unit: senml.Joule, Time: 1.320067524e+09, Sum: senml.Float(1200)},
+			{Name: "urn:dev:ow:10e2073a01080063:open", Time: 1.320067585e+09, BoolValue: senml.True},
+			{Name: "urn:dev:ow:10e2073a01080063:open", Time: 1.320067585e+09, BoolValue: senml.False},
+			{Name: "urn:dev:ow:10e2073a01080063:label", Time: 1.320067700e+09, StringValue: "kitchen"},
+			{Name: "urn:dev:ow:10e2073a01080063:raw", Time: 1.320067700e+09, DataValue: []byte{0xca, 0xfe}},
+			{Name: "urn:dev:ow:10e2073a01080063:empty", Time: 1.420067700e+09, DataValue: []byte{}},
+			{Name: "urn:dev:ow:10e2073a01080063:none", Time: -1.320067700e+09},
+			{Name: "urn:dev:ow:10e2073a01080063:temp", Unit: senml.Celsius, Time: 1.320067524e+09, Value: senml.Float(-23.4)},
+			{Name: "urn:dev:ow:10e2073a01080063:temp", Unit: senml.Celsius, Time: 1.320067524e+09, Value: senml.Float(math.Inf(1))},
+			{Name: "urn:dev:ow:10e2073a01080063:temp", Unit: senml.Celsius, Time: 1.320067524e+09, Value: senml.Float(0)},
+		},
+		{
+			// times which are not an exact number of nanoseconds
+			{Name: "a", Time: 1.0 / 3, Value: senml.Float(1)},
+			{Name: "a", Time: 2.0 / 3, Value: senml.Float(2)},
+			{Name: "a", Time: 1, Value: senml.Float(3)},
+		},
+	}
+	for _, tc := range tcs {
+		enc, err := Marshal(tc)
+		if err != nil {
+			t.Errorf("encoding of %+v returned an error : %s", tc, err)
+			continue
+		}
+		dec, err := Unmarshal(enc)
+		if err != nil {
+			t.Errorf("decoding of %+v returned an error : %s", tc, err)
+			continue
+		}
+		if !reflect.DeepEqual(tc, dec) {
+			t.Errorf("decoding of %x should be %+v not %+v", enc, tc, dec)
+		}
+	}
+}
+
+func TestCompression(t *testing.T) {
+	p := make(senml.Pack, 0, 3000)
+	for i := 0; i < 1000; i++ {
+		ts := 1.5e+09 + float64(i)*60
+		p = append(p,
+			senml.Record{Name: "urn:dev:ow:10e2073a01080063:temp", Unit: senml.Celsius, Time: ts, Value: senml.Float(20 + float64(i%10)/2)},
+			senml.Record{Name: "urn:dev:ow:10e2073a01080063:hum", Unit: senml.RelativeHumidity, Time: ts, Value: senml.Float(40)},
+			senml.Record{Name: "urn:dev:ow:10e2073a01080063:open", Time: ts, BoolValue: senml.Bool(i%2 == 0)},
+		)
+	}
+	enc, err := Marshal(p)
+	if err != nil {
+		t.Fatalf("encoding returned an error : %s", err)
+	}
+	js, _ := json.Marshal(p)
+	if len(enc)*5 > len(js) {
+		t.Errorf("encoding should be at least 5 times smaller than JSON (%d bytes), not %d bytes", len(js), len(enc))
+	}
+	dec, err := Unmarshal(enc)
+	if err != nil {
+		t.Fatalf("decoding returned an error : %s", err)
+	}
+	if !p.Equals(dec) {
+		t.Errorf("decoding should return the encoded pack")
+	}
+}
+
+func TestFloatEncoding(t *testing.T) {
+	values := make([]float64, 1000)
+	for i := range values {
+		values[i] = 20 + float64(i%10)/2
+	}
+	values = append(values, 0, math.NaN(), math.Inf(-1), math.MaxFloat64, math.SmallestNonzeroFloat64, -0.1, -0.1, 0)
+	e := floatEncoder{}
+	for _, f := range values {
+		e.write(f)
+	}
+	if size := len(e.w.buf); size >= 8*len(values) {
+		t.Errorf("encoding of %d changing values should be smaller than %d bytes, not %d bytes", len(values), 8*len(values), size)
+	}
+	d := floatDecoder{r: bitReader{buf: e.w.buf}}
+	for _, f := range values {
+		dec, err := d.read()
+		if err != nil || (dec != f && !(math.IsNaN(f) && math.IsNaN(dec))) {
+			t.Errorf("value %v decoded as %v (%v)", f, dec, err)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, p := range []senml.Pack{
+		{{BaseName: "foo", Value: senml.Float(1)}},
+		{{Name: "foo", UpdateTime: 1, Value: senml.Float(1)}},
+		{{Name: "foo", Value: senml.Float(1), BoolValue: senml.True}},
+	} {
+		if _, err := Marshal(p); err != ErrNotNormalized {
+			t.Errorf("encoding of %+v should return ErrNotNormalized, not %v", p, err)
+		}
+	}
+
+	enc, _ := Marshal(senml.Pack{
+		{Name: "foo", Time: 1, Value: senml.Float(1)},
+		{Name: "foo", Time: 2, StringValue: "bar"},
+		{Name: "foo", Time: 3, DataValue: []byte{1}},
+	})
+	for i := 0; i < len(enc); i++ {
+		if _, err := Unmarshal(enc[:i]); err != ErrInvalidData {
+			t.Errorf("decoding of truncated data %x should return ErrInvalidData, not %v", enc[:i], err)
+		}
+	}
+	if _, err := Unmarshal(append(enc, 0)); err != ErrInvalidData {
+		t.Errorf("decoding of data with trailing bytes should return ErrInvalidData, not %v", err)
+	}
+}
+
+func TestTimeEncoding(t *testing.T) {
+	times := []int64{0, 1, 2, 3, 100, 200, 1000, 1001, 5000, 5000, -5000, math.MaxInt64, math.MinInt64, 0}
+	e := timeEncoder{}
+	for _, ts := range times {
+		e.write(ts)
+	}
+	d := timeDecoder{r: bitReader{buf: e.w.buf}}
+	for _, ts := range times {
+		dec, err := d.read()
+		if err != nil || dec != ts {
+			t.Errorf("time %d decoded as %d (%v)", ts, dec, err)
+		}
+	}
+}
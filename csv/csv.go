@@ -0,0 +1,366 @@
+// Package csv encodes and decodes SenML packs as CSV files.
+//
+// In Long mode, each record is a row, with a column per record field, named after its SenML label.
+// In Wide mode, each row holds the values of all names at a given time,
+// with a "t" column followed by a column per resolved name.
+package csv
+
+import (
+	stdcsv "encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/objenious/senml"
+)
+
+// Mode is the layout of the CSV file.
+type Mode int
+
+const (
+	// Long layout : a row per record.
+	Long Mode = iota
+	// Wide layout : a row per time, a column per resolved name.
+	Wide
+)
+
+// TimeFormat is the format of times.
+type TimeFormat int
+
+const (
+	// Epoch formats times as SenML times (seconds since the epoch).
+	Epoch TimeFormat = iota
+	// RFC3339 formats times as RFC3339 dates, with nanoseconds.
+	RFC3339
+)
+
+// Options define how packs are encoded.
+type Options struct {
+	Mode       Mode
+	TimeFormat TimeFormat
+}
+
+var (
+	// ErrInvalidHeader is returned when the header of a CSV file contains an unknown or duplicate column.
+	ErrInvalidHeader = errors.New("csv: invalid header")
+	// ErrDuplicateCell is returned when encoding in Wide mode a pack with several values for a name at the same time.
+	ErrDuplicateCell = errors.New("csv: several values for a name at the same time")
+)
+
+// columns are the columns in Long mode.
+var columns = []string{"bn", "bt", "bu", "bv", "bs", "bver", "n", "u", "t", "ut", "v", "vs", "vd", "vb", "s"}
+
+// Write encodes a pack as CSV. In Wide mode, the pack is normalized, and ErrDuplicateCell is returned
+// if several records have the same resolved name and time. Data values are encoded as base64url.
+func Write(w io.Writer, p senml.Pack, opts Options) error {
+	cw := stdcsv.NewWriter(w)
+	var err error
+	if opts.Mode == Wide {
+		err = writeWide(cw, p, opts)
+	} else {
+		err = writeLong(cw, p, opts)
+	}
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Read decodes a CSV file to a pack.
+// Times can be formatted either as SenML times or RFC3339 dates, regardless of opts.TimeFormat.
+// In Wide mode, the value kinds are guessed : numbers are decoded as values, "true" and "false" as booleans,
+// and other cells as strings. Empty cells are ignored.
+func Read(r io.Reader, opts Options) (senml.Pack, error) {
+	cr := stdcsv.NewReader(r)
+	cr.FieldsPerRecord = 0
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return senml.Pack{}, nil
+	}
+	if opts.Mode == Wide {
+		return readWide(rows)
+	}
+	return readLong(rows)
+}
+
+func writeLong(cw *stdcsv.Writer, p senml.Pack, opts Options) error {
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	row := make([]string, len(columns))
+	for _, r := range p {
+		row[0] = r.BaseName
+		row[1] = formatTime(r.BaseTime, opts.TimeFormat)
+		row[2] = string(r.BaseUnit)
+		row[3] = formatFloatPtr(r.BaseValue)
+		row[4] = formatFloatPtr(r.BaseSum)
+		row[5] = ""
+		if r.BaseVersion != 0 {
+			row[5] = strconv.Itoa(r.BaseVersion)
+		}
+		row[6] = r.Name
+		row[7] = string(r.Unit)
+		row[8] = formatTime(r.Time, opts.TimeFormat)
+		row[9] = ""
+		if r.UpdateTime != 0 {
+			row[9] = formatFloat(r.UpdateTime)
+		}
+		row[10] = formatFloatPtr(r.Value)
+		row[11] = r.StringValue
+		row[12] = ""
+		if r.DataValue != nil {
+			row[12] = senml.EncodeDataValue(r.DataValue)
+		}
+		row[13] = ""
+		if r.BoolValue != nil {
+			row[13] = strconv.FormatBool(*r.BoolValue)
+		}
+		row[14] = formatFloatPtr(r.Sum)
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readLong(rows [][]string) (senml.Pack, error) {
+	idx := map[string]int{}
+	for i, col := range rows[0] {
+		if _, found := idx[col]; found {
+			return nil, ErrInvalidHeader
+		}
+		idx[col] = i
+	}
+	for col := range idx {
+		found := false
+		for _, c := range columns {
+			found = found || c == col
+		}
+		if !found {
+			return nil, ErrInvalidHeader
+		}
+	}
+	p := make(senml.Pack, 0, len(rows)-1)
+	for line, row := range rows[1:] {
+		get := func(col string) string {
+			if i, found := idx[col]; found && i < len(row) {
+				return row[i]
+			}
+			return ""
+		}
+		var r senml.Record
+		var err error
+		r.BaseName = get("bn")
+		r.BaseUnit = senml.Unit(get("bu"))
+		r.Name = get("n")
+		r.Unit = senml.Unit(get("u"))
+		r.StringValue = get("vs")
+		if r.BaseTime, err = parseTime(get("bt")); err != nil {
+			return nil, rowError(line, "bt", err)
+		}
+		if r.Time, err = parseTime(get("t")); err != nil {
+			return nil, rowError(line, "t", err)
+		}
+		if s := get("ut"); s != "" {
+			if r.UpdateTime, err = strconv.ParseFloat(s, 64); err != nil {
+				return nil, rowError(line, "ut", err)
+			}
+		}
+		if s := get("bver"); s != "" {
+			if r.BaseVersion, err = strconv.Atoi(s); err != nil {
+				return nil, rowError(line, "bver", err)
+			}
+		}
+		if r.BaseValue, err = parseFloatPtr(get("bv")); err != nil {
+			return nil, rowError(line, "bv", err)
+		}
+		if r.BaseSum, err = parseFloatPtr(get("bs")); err != nil {
+			return nil, rowError(line, "bs", err)
+		}
+		if r.Value, err = parseFloatPtr(get("v")); err != nil {
+			return nil, rowError(line, "v", err)
+		}
+		if r.Sum, err = parseFloatPtr(get("s")); err != nil {
+			return nil, rowError(line, "s", err)
+		}
+		if s := get("vd"); s != "" {
+			if r.DataValue, err = senml.DecodeDataValue(s); err != nil {
+				return nil, rowError(line, "vd", err)
+			}
+		}
+		if s := get("vb"); s != "" {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return nil, rowError(line, "vb", err)
+			}
+			r.BoolValue = senml.Bool(b)
+		}
+		p = append(p, r)
+	}
+	return p, nil
+}
+
+func writeWide(cw *stdcsv.Writer, p senml.Pack, opts Options) error {
+	p = p.Normalize()
+	var names []string
+	cols := map[string]int{}
+	units := map[string]senml.Unit{}
+	var times []float64
+	cells := map[float64]map[string]string{}
+	for _, r := range p {
+		if _, found := cols[r.Name]; !found {
+			cols[r.Name] = len(names)
+			names = append(names, r.Name)
+		}
+		if r.Unit != "" {
+			units[r.Name] = r.Unit
+		}
+		if _, found := cells[r.Time]; !found {
+			cells[r.Time] = map[string]string{}
+			times = append(times, r.Time)
+		}
+		if _, found := cells[r.Time][r.Name]; found {
+			return ErrDuplicateCell
+		}
+		cells[r.Time][r.Name] = formatValue(r)
+	}
+	sort.Float64s(times)
+
+	header := make([]string, len(names)+1)
+	header[0] = "t"
+	for i, name := range names {
+		header[i+1] = name
+		if u := units[name]; u != "" {
+			header[i+1] += " (" + string(u) + ")"
+		}
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	row := make([]string, len(names)+1)
+	for _, t := range times {
+		row[0] = formatTime(t, opts.TimeFormat)
+		for i, name := range names {
+			row[i+1] = cells[t][name]
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readWide(rows [][]string) (senml.Pack, error) {
+	header := rows[0]
+	if len(header) < 1 || header[0] != "t" {
+		return nil, ErrInvalidHeader
+	}
+	names := make([]string, len(header))
+	units := make([]senml.Unit, len(header))
+	for i, col := range header[1:] {
+		names[i+1] = col
+		if strings.HasSuffix(col, ")") {
+			if j := strings.LastIndex(col, " ("); j >= 0 {
+				names[i+1] = col[:j]
+				units[i+1] = senml.Unit(col[j+2 : len(col)-1])
+			}
+		}
+	}
+	p := senml.Pack{}
+	for line, row := range rows[1:] {
+		t, err := parseTime(row[0])
+		if err != nil {
+			return nil, rowError(line, "t", err)
+		}
+		for i := 1; i < len(row) && i < len(names); i++ {
+			if row[i] == "" {
+				continue
+			}
+			r := senml.Record{Name: names[i], Unit: units[i], Time: t}
+			if f, err := strconv.ParseFloat(row[i], 64); err == nil {
+				r.Value = senml.Float(f)
+			} else if row[i] == "true" || row[i] == "false" {
+				r.BoolValue = senml.Bool(row[i] == "true")
+			} else {
+				r.StringValue = row[i]
+			}
+			p = append(p, r)
+		}
+	}
+	return p, nil
+}
+
+func formatValue(r senml.Record) string {
+	switch {
+	case r.Value != nil:
+		return formatFloat(*r.Value)
+	case r.BoolValue != nil:
+		return strconv.FormatBool(*r.BoolValue)
+	case r.StringValue != "":
+		return r.StringValue
+	case r.DataValue != nil:
+		return senml.EncodeDataValue(r.DataValue)
+	case r.Sum != nil:
+		return formatFloat(*r.Sum)
+	}
+	return ""
+}
+
+func formatTime(t float64, f TimeFormat) string {
+	if t == 0 {
+		return ""
+	}
+	if f == RFC3339 {
+		return senml.GoTime(t).UTC().Format(time.RFC3339Nano)
+	}
+	// avoid the exponent notation, which is not understood by all spreadsheets
+	return strconv.FormatFloat(t, 'f', -1, 64)
+}
+
+func parseTime(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return 0, err
+	}
+	return senml.Time(t), nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func formatFloatPtr(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return formatFloat(*f)
+}
+
+func parseFloatPtr(s string) (*float64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func rowError(line int, col string, err error) error {
+	return fmt.Errorf("csv: row %d, column %s: %s", line+1, col, err)
+}
@@ -0,0 +1,147 @@
+package csv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/objenious/senml"
+)
+
+func TestLong(t *testing.T) {
+	tcs := []struct {
+		src  senml.Pack
+		opts Options
+		csv  string
+	}{
+		{
+			src: senml.Pack{
+				{BaseName: "urn:dev:ow:10e2073a01080063:", BaseTime: 1.320067464e+09, BaseUnit: senml.Celsius, BaseVersion: 10, Name: "temp", Value: senml.Float(23.1)},
+				{Name: "open", Time: -60, BoolValue: senml.True},
+				{Name: "label", UpdateTime: 300, StringValue: "kitchen, north"},
+				{BaseValue: senml.Float(1), BaseSum: senml.Float(2), Name: "raw", DataValue: []byte{0xca, 0xfe}},
+				{Name: "energy", Unit: senml.Joule, Sum: senml.Float(1200)},
+			},
+			csv: `bn,bt,bu,bv,bs,bver,n,u,t,ut,v,vs,vd,vb,s
+urn:dev:ow:10e2073a01080063:,1320067464,Cel,,,10,temp,,,,23.1,,,,
+,,,,,,open,,-60,,,,,true,
+,,,,,,label,,,300,,"kitchen, north",,,
+,,,1,2,,raw,,,,,,yv4,,
+,,,,,,energy,J,,,,,,,1200
+`,
+		},
+		{
+			src: senml.Pack{
+				{Name: "temp", Time: 1.5000000001e+09, Value: senml.Float(20)},
+			},
+			opts: Options{TimeFormat: RFC3339},
+			csv: `bn,bt,bu,bv,bs,bver,n,u,t,ut,v,vs,vd,vb,s
+,,,,,,temp,,2017-07-14T02:40:00.1Z,,20,,,,
+`,
+		},
+	}
+	for _, tc := range tcs {
+		var buf bytes.Buffer
+		if err := Write(&buf, tc.src, tc.opts); err != nil {
+			t.Errorf("encoding of %+v returned an error : %s", tc.src, err)
+		}
+		if buf.String() != tc.csv {
+			t.Errorf("encoding of %+v should be\n%s\nnot\n%s", tc.src, tc.csv, buf.String())
+		}
+		dec, err := Read(strings.NewReader(tc.csv), tc.opts)
+		if err != nil {
+			t.Errorf("decoding of %s returned an error : %s", tc.csv, err)
+		}
+		if !tc.src.Equals(dec) {
+			t.Errorf("decoding of %s should be %+v not %+v", tc.csv, tc.src, dec)
+		}
+	}
+}
+
+func TestReadLong(t *testing.T) {
+	dec, err := Read(strings.NewReader("n,v\ntemp,1\n"), Options{})
+	if err != nil {
+		t.Fatalf("decoding a partial header returned an error : %s", err)
+	}
+	if exp := (senml.Pack{{Name: "temp", Value: senml.Float(1)}}); !exp.Equals(dec) {
+		t.Errorf("decoding a partial header should return %+v not %+v", exp, dec)
+	}
+	if dec, err := Read(strings.NewReader(""), Options{}); err != nil || len(dec) != 0 {
+		t.Errorf("decoding an empty file should return an empty pack, not %+v (%v)", dec, err)
+	}
+	for _, csv := range []string{"n,foo\n", "n,n\n"} {
+		if _, err := Read(strings.NewReader(csv), Options{}); err != ErrInvalidHeader {
+			t.Errorf("decoding of %s should return ErrInvalidHeader, not %v", csv, err)
+		}
+	}
+	for _, csv := range []string{"t\nfoo\n", "bt\nfoo\n", "ut\nfoo\n", "bver\nfoo\n", "bv\nfoo\n", "bs\nfoo\n", "v\nfoo\n", "s\nfoo\n", "vd\n!\n", "vb\nfoo\n", "n,v\nfoo\n"} {
+		if _, err := Read(strings.NewReader(csv), Options{}); err == nil {
+			t.Errorf("decoding of %s should return an error", csv)
+		}
+	}
+}
+
+func TestWide(t *testing.T) {
+	src := senml.Pack{
+		{Name: "urn:dev:ow:10e2073a01080063:temp", Unit: senml.Celsius, Time: 1.320067464e+09, Value: senml.Float(23.1)},
+		{Name: "urn:dev:ow:10e2073a01080063:open", Time: 1.320067464e+09, BoolValue: senml.False},
+		{Name: "urn:dev:ow:10e2073a01080063:label", Time: 1.320067524e+09, StringValue: "kitchen"},
+		{Name: "urn:dev:ow:10e2073a01080063:temp", Unit: senml.Celsius, Time: 1.320067524e+09, Value: senml.Float(23.4)},
+	}
+	tcs := []struct {
+		opts Options
+		csv  string
+	}{
+		{
+			opts: Options{Mode: Wide},
+			csv: `t,urn:dev:ow:10e2073a01080063:temp (Cel),urn:dev:ow:10e2073a01080063:open,urn:dev:ow:10e2073a01080063:label
+1320067464,23.1,false,
+1320067524,23.4,,kitchen
+`,
+		},
+		{
+			opts: Options{Mode: Wide, TimeFormat: RFC3339},
+			csv: `t,urn:dev:ow:10e2073a01080063:temp (Cel),urn:dev:ow:10e2073a01080063:open,urn:dev:ow:10e2073a01080063:label
+2011-10-31T13:24:24Z,23.1,false,
+2011-10-31T13:25:24Z,23.4,,kitchen
+`,
+		},
+	}
+	for _, tc := range tcs {
+		var buf bytes.Buffer
+		if err := Write(&buf, src, tc.opts); err != nil {
+			t.Errorf("encoding of %+v returned an error : %s", src, err)
+		}
+		if buf.String() != tc.csv {
+			t.Errorf("encoding of %+v should be\n%s\nnot\n%s", src, tc.csv, buf.String())
+		}
+		dec, err := Read(strings.NewReader(tc.csv), tc.opts)
+		if err != nil {
+			t.Errorf("decoding of %s returned an error : %s", tc.csv, err)
+		}
+		if exp := (senml.Pack{src[0], src[1], src[3], src[2]}); !exp.Equals(dec) {
+			t.Errorf("decoding of %s should be %+v not %+v", tc.csv, exp, dec)
+		}
+	}
+	// the pack is normalized
+	var buf bytes.Buffer
+	p := senml.Pack{
+		{BaseName: "urn:dev:ow:10e2073a01080063:", BaseTime: 1.320067524e+09, Name: "label", StringValue: "kitchen"},
+		{Name: "temp", Unit: senml.Celsius, Time: -60, Value: senml.Float(23.1)},
+		{Name: "open", Time: -60, BoolValue: senml.False},
+		{Name: "temp", Unit: senml.Celsius, Value: senml.Float(23.4)},
+	}
+	if err := Write(&buf, p, Options{Mode: Wide}); err != nil || buf.String() != tcs[0].csv {
+		t.Errorf("encoding of %+v should be\n%s\nnot\n%s (%v)", p, tcs[0].csv, buf.String(), err)
+	}
+	p = append(p, senml.Record{Name: "temp", Unit: senml.Celsius, Value: senml.Float(23.5)})
+	if err := Write(&buf, p, Options{Mode: Wide}); err != ErrDuplicateCell {
+		t.Errorf("encoding of %+v should return ErrDuplicateCell, not %v", p, err)
+	}
+
+	for _, csv := range []string{"temp\n1\n", "t,temp\nfoo,1\n"} {
+		if _, err := Read(strings.NewReader(csv), Options{Mode: Wide}); err == nil {
+			t.Errorf("decoding of %s should return an error", csv)
+		}
+	}
+}
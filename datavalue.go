@@ -0,0 +1,36 @@
+package senml
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidDataValue is returned when decoding a data value which is not valid base64url.
+var ErrInvalidDataValue = errors.New("senml: invalid data value")
+
+// EncodeDataValue encodes a data value as in the JSON and XML representations :
+// base64url without padding, as required by https://tools.ietf.org/html/rfc8428#section-4.3.
+func EncodeDataValue(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeDataValue decodes a base64url data value.
+// Padding, and the standard base64 alphabet used by legacy producers, are accepted.
+func DecodeDataValue(s string) ([]byte, error) {
+	s = strings.TrimRight(s, "=")
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case '+':
+			return '-'
+		case '/':
+			return '_'
+		}
+		return r
+	}, s)
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidDataValue
+	}
+	return b, nil
+}
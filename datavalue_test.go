@@ -0,0 +1,95 @@
+package senml
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+)
+
+func TestDataValue(t *testing.T) {
+	// test vectors from https://tools.ietf.org/html/rfc4648#section-10, and bytes specific to the base64url alphabet
+	tcs := []struct {
+		data []byte
+		enc  string
+	}{
+		{data: []byte(""), enc: ""},
+		{data: []byte("f"), enc: "Zg"},
+		{data: []byte("fo"), enc: "Zm8"},
+		{data: []byte("foo"), enc: "Zm9v"},
+		{data: []byte("foob"), enc: "Zm9vYg"},
+		{data: []byte("fooba"), enc: "Zm9vYmE"},
+		{data: []byte("foobar"), enc: "Zm9vYmFy"},
+		{data: []byte{0xfb, 0xff}, enc: "-_8"},
+	}
+	for _, tc := range tcs {
+		if enc := EncodeDataValue(tc.data); enc != tc.enc {
+			t.Errorf("encoding of %x should be %s not %s", tc.data, tc.enc, enc)
+		}
+		dec, err := DecodeDataValue(tc.enc)
+		if err != nil || !bytes.Equal(dec, tc.data) {
+			t.Errorf("decoding of %s should be %x not %x (%v)", tc.enc, tc.data, dec, err)
+		}
+	}
+
+	// padded and standard base64 input is accepted
+	for _, enc := range []string{"-_8=", "+/8=", "+/8"} {
+		if dec, err := DecodeDataValue(enc); err != nil || !bytes.Equal(dec, []byte{0xfb, 0xff}) {
+			t.Errorf("decoding of %s should be fbff not %x (%v)", enc, dec, err)
+		}
+	}
+	for _, enc := range []string{"!", "Z", "Zm9v YmFy"} {
+		if _, err := DecodeDataValue(enc); err != ErrInvalidDataValue {
+			t.Errorf("decoding of %s should return ErrInvalidDataValue, not %v", enc, err)
+		}
+	}
+}
+
+func TestDataValueEncoding(t *testing.T) {
+	// https://tools.ietf.org/html/rfc8428#section-5.1.4, with an additional data value
+	src := Pack{
+		{BaseName: "urn:dev:ow:10e2073a01080063:", BaseTime: 1.320067464e+09, BaseUnit: RelativeHumidity, Name: "temperature", Unit: Celsius, Value: Float(27.2)},
+		{Name: "humidity", Value: Float(80)},
+		{Name: "raw", DataValue: []byte{0xfb, 0xff, 0x01}},
+	}
+	js := `[{"bn":"urn:dev:ow:10e2073a01080063:","bt":1320067464,"bu":"%RH","n":"temperature","u":"Cel","v":27.2},{"n":"humidity","v":80},{"n":"raw","vd":"-_8B"}]`
+	x := `<sensml xmlns="urn:ietf:params:xml:ns:senml"><senml bn="urn:dev:ow:10e2073a01080063:" bt="1.320067464e+09" bu="%RH" n="temperature" u="Cel" v="27.2"></senml><senml n="humidity" v="80"></senml><senml n="raw" vd="-_8B"></senml></sensml>`
+
+	enc, err := json.Marshal(src)
+	if err != nil || string(enc) != js {
+		t.Errorf("JSON encoding of %+v should be %s not %s (%v)", src, js, enc, err)
+	}
+	enc, err = xml.Marshal(src)
+	if err != nil || string(enc) != x {
+		t.Errorf("XML encoding of %+v should be %s not %s (%v)", src, x, enc, err)
+	}
+
+	for _, js := range []string{js, `[{"n":"raw","vd":"+/8B"}]`, `[{"n":"raw","vd":"-_8B"}]`} {
+		var dec Pack
+		if err := json.Unmarshal([]byte(js), &dec); err != nil {
+			t.Errorf("JSON decoding of %s returned an error : %s", js, err)
+			continue
+		}
+		if r := dec[len(dec)-1]; !bytes.Equal(r.DataValue, src[2].DataValue) {
+			t.Errorf("JSON decoding of %s should return data %x not %x", js, src[2].DataValue, r.DataValue)
+		}
+	}
+	for _, x := range []string{x, `<sensml xmlns="urn:ietf:params:xml:ns:senml"><senml n="raw" vd="-_8B"/></sensml>`} {
+		var dec Pack
+		if err := xml.Unmarshal([]byte(x), &dec); err != nil {
+			t.Errorf("XML decoding of %s returned an error : %s", x, err)
+			continue
+		}
+		if r := dec[len(dec)-1]; !bytes.Equal(r.DataValue, src[2].DataValue) {
+			t.Errorf("XML decoding of %s should return data %x not %x", x, src[2].DataValue, r.DataValue)
+		}
+	}
+
+	var dec Pack
+	if err := json.Unmarshal([]byte(`[{"vd":"!"}]`), &dec); err != ErrInvalidDataValue {
+		t.Errorf("JSON decoding of an invalid data value should return ErrInvalidDataValue, not %v", err)
+	}
+	if err := xml.Unmarshal([]byte(`<sensml xmlns="urn:ietf:params:xml:ns:senml"><senml vd="!"/></sensml>`), &dec); err == nil {
+		t.Errorf("XML decoding of an invalid data value should return an error")
+	}
+}
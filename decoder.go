@@ -0,0 +1,29 @@
+package senml
+
+// JSONDecoder decodes JSON packs without allocating, once its storage has grown to the size of the decoded packs.
+//
+// The backing array of the destination pack, the strings and data values of its records
+// (when the new values fit), and the storage of value pointers are reused by each call to Decode :
+// a pack returned by a previous call, or any pointer to its values, must not be used after the next call.
+// Strings which differ from the previous ones are allocated.
+//
+// The zero value is ready to use. A JSONDecoder must not be used concurrently, but can be kept in a sync.Pool.
+type JSONDecoder struct {
+	floats []float64
+	bools  []bool
+}
+
+// Decode decodes a JSON pack to p, reusing the storage of p and of previous calls.
+// If an error is returned, the records in the backing array of p may have been modified.
+func (dec *JSONDecoder) Decode(data []byte, p *Pack) error {
+	d := jsonDecoder{data: data, floats: dec.floats[:0], bools: dec.bools[:0], reuse: true}
+	err := d.pack(p)
+	// the pointers did not fit in the storage : grow it for the next call
+	if d.nfloats > cap(dec.floats) {
+		dec.floats = make([]float64, 0, d.nfloats)
+	}
+	if d.nbools > cap(dec.bools) {
+		dec.bools = make([]bool, 0, d.nbools)
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+package senml
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestJSONDecoder(t *testing.T) {
+	src := Pack{
+		{BaseName: "urn:dev:ow:10e2073a01080063:", BaseTime: 1.320067464e+09, BaseValue: Float(1), Name: "temp", Unit: Celsius, Value: Float(23.1)},
+		{Name: "energy", Unit: Joule, Time: -60, Sum: Float(1200)},
+		{Name: "open", BoolValue: True},
+		{Name: "label", StringValue: "kitchen"},
+		{Name: "raw", DataValue: []byte{0xca, 0xfe}},
+	}
+	js, _ := json.Marshal(src)
+	other := Pack{{Name: "other", Value: Float(1), BoolValue: False}}
+	js2, _ := json.Marshal(other)
+
+	dec := JSONDecoder{}
+	var p Pack
+	for i := 0; i < 3; i++ {
+		if err := dec.Decode(js, &p); err != nil {
+			t.Fatalf("decoding of %s returned an error : %s", js, err)
+		}
+		if !reflect.DeepEqual(p, src) {
+			t.Errorf("decoding of %s should be %+v not %+v", js, src, p)
+		}
+		if err := dec.Decode(js2, &p); err != nil {
+			t.Fatalf("decoding of %s returned an error : %s", js2, err)
+		}
+		if !reflect.DeepEqual(p, other) {
+			t.Errorf("decoding of %s should be %+v not %+v", js2, other, p)
+		}
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		dec.Decode(js, &p)
+	})
+	if allocs != 0 {
+		t.Errorf("decoding with a JSONDecoder should not allocate, not allocate %v times", allocs)
+	}
+
+	if err := dec.Decode([]byte(`[{"v":"1"}]`), &p); err == nil {
+		t.Errorf("decoding of invalid JSON should return an error")
+	}
+}
+
+func BenchmarkJSONDecoder(b *testing.B) {
+	js, _ := json.Marshal(benchmarkPack())
+	dec := JSONDecoder{}
+	var p Pack
+	dec.Decode(js, &p)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dec.Decode(js, &p)
+	}
+}
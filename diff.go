@@ -0,0 +1,192 @@
+package senml
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind is the kind of a Change.
+type ChangeKind int
+
+const (
+	// Added records are only in the second pack.
+	Added ChangeKind = iota
+	// Removed records are only in the first pack.
+	Removed
+	// Changed records are in both packs, with different fields.
+	Changed
+)
+
+// FieldChange is a field which differs between two records.
+type FieldChange struct {
+	// Label is the SenML label of the field, e.g. "v" or "u".
+	Label string
+	// Old and New are the formatted values of the field, or an empty string if it is not set.
+	Old, New string
+}
+
+// Change is a difference between two packs.
+type Change struct {
+	Kind ChangeKind
+	// Name and Time are the resolved name and time of the record.
+	Name string
+	Time float64
+	// Old and New are the normalized records, nil for added and removed records respectively.
+	Old, New *Record
+	// Fields are the fields which differ, for changed records.
+	Fields []FieldChange
+}
+
+// Changes are the differences between two packs.
+type Changes []Change
+
+// Diff compares two packs. Records are normalized, and matched by resolved name and time
+// (records with the same name and time are matched in order).
+// Removed and changed records are returned in the order of a, followed by the added records in the order of b.
+func Diff(a, b Pack) Changes {
+	na, nb := a.Normalize(), b.Normalize()
+	type key struct {
+		name string
+		time float64
+	}
+	idx := map[key][]int{}
+	for i := range nb {
+		k := key{nb[i].Name, nb[i].Time}
+		idx[k] = append(idx[k], i)
+	}
+	matched := make([]bool, len(nb))
+	var changes Changes
+	for i := range na {
+		r := &na[i]
+		k := key{r.Name, r.Time}
+		if len(idx[k]) == 0 {
+			changes = append(changes, Change{Kind: Removed, Name: r.Name, Time: r.Time, Old: r})
+			continue
+		}
+		j := idx[k][0]
+		idx[k] = idx[k][1:]
+		matched[j] = true
+		if fields := diffFields(r, &nb[j]); len(fields) > 0 {
+			changes = append(changes, Change{Kind: Changed, Name: r.Name, Time: r.Time, Old: r, New: &nb[j], Fields: fields})
+		}
+	}
+	for j := range nb {
+		if !matched[j] {
+			changes = append(changes, Change{Kind: Added, Name: nb[j].Name, Time: nb[j].Time, New: &nb[j]})
+		}
+	}
+	return changes
+}
+
+// diffFields returns the fields which differ between two normalized records.
+func diffFields(a, b *Record) []FieldChange {
+	fa, fb := formatFields(a), formatFields(b)
+	var fields []FieldChange
+	for i := range fa {
+		if fa[i].value != fb[i].value {
+			fields = append(fields, FieldChange{Label: fa[i].label, Old: fa[i].value, New: fb[i].value})
+		}
+	}
+	return fields
+}
+
+type formattedField struct {
+	label, value string
+}
+
+// formatFields formats the fields of a normalized record, except its name and time.
+func formatFields(r *Record) []formattedField {
+	f := []formattedField{
+		{label: "bver"},
+		{label: "u", value: string(r.Unit)},
+		{label: "v", value: formatFloatPtr(r.Value)},
+		{label: "vs", value: r.StringValue},
+		{label: "vd"},
+		{label: "vb"},
+		{label: "s", value: formatFloatPtr(r.Sum)},
+	}
+	if r.BaseVersion != 0 {
+		f[0].value = strconv.Itoa(r.BaseVersion)
+	}
+	if r.DataValue != nil {
+		f[4].value = EncodeDataValue(r.DataValue)
+	}
+	if r.BoolValue != nil {
+		f[5].value = strconv.FormatBool(*r.BoolValue)
+	}
+	return f
+}
+
+func formatFloatPtr(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return formatDiffFloat(*f)
+}
+
+func formatDiffFloat(f float64) string {
+	if math.Abs(f) >= 1e21 || f != f {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	// avoid the exponent notation for times
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// String formats the change on a line : "+" for added records, "-" for removed records and "~" for changed records,
+// followed by the name and time of the record, and its fields or the fields which differ.
+func (c Change) String() string {
+	var sb bytes.Buffer
+	switch c.Kind {
+	case Added:
+		sb.WriteString("+ ")
+	case Removed:
+		sb.WriteString("- ")
+	default:
+		sb.WriteString("~ ")
+	}
+	sb.WriteString(c.Name)
+	sb.WriteString(" at ")
+	sb.WriteString(formatDiffFloat(c.Time))
+	sb.WriteString(":")
+	switch c.Kind {
+	case Changed:
+		for i, f := range c.Fields {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(" " + f.Label + " " + quoteField(f.Old) + " -> " + quoteField(f.New))
+		}
+	default:
+		r := c.New
+		if c.Kind == Removed {
+			r = c.Old
+		}
+		for _, f := range formatFields(r) {
+			if f.value != "" {
+				sb.WriteString(" " + f.label + "=" + quoteField(f.value))
+			}
+		}
+	}
+	return sb.String()
+}
+
+func quoteField(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	if strings.ContainsAny(s, " \t\n\"") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// String formats the changes, one per line.
+func (c Changes) String() string {
+	lines := make([]string, len(c))
+	for i := range c {
+		lines[i] = c[i].String()
+	}
+	return strings.Join(lines, "\n")
+}
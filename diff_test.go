@@ -0,0 +1,99 @@
+package senml
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tcs := []struct {
+		name string
+		a    Pack
+		b    Pack
+		res  string
+	}{
+		{
+			name: "equal",
+			a: Pack{
+				{BaseName: "dev:", BaseTime: 100, Name: "temp", Unit: Celsius, Value: Float(21)},
+				{Name: "open", BoolValue: Bool(true)},
+			},
+			b: Pack{
+				{Name: "dev:temp", Time: 100, Unit: Celsius, Value: Float(21)},
+				{Name: "dev:open", Time: 100, BoolValue: Bool(true)},
+			},
+			res: "",
+		},
+		{
+			name: "NaN",
+			a:    Pack{{Name: "temp", Value: Float(math.NaN())}},
+			b:    Pack{{Name: "temp", Value: Float(math.NaN())}},
+			res:  "",
+		},
+		{
+			name: "changed",
+			a: Pack{
+				{BaseName: "dev:", Name: "temp", Time: 1320067464, Unit: Celsius, Value: Float(21)},
+				{BaseName: "dev:", Name: "label", Time: 1320067464, StringValue: "old label"},
+			},
+			b: Pack{
+				{BaseName: "dev:", Name: "temp", Time: 1320067464, Unit: Kelvin, Value: Float(294.15)},
+				{BaseName: "dev:", Name: "label", Time: 1320067464, DataValue: []byte{1, 2}},
+			},
+			res: "~ dev:temp at 1320067464: u Cel -> K, v 21 -> 294.15\n" +
+				"~ dev:label at 1320067464: vs \"old label\" -> (none), vd (none) -> AQI",
+		},
+		{
+			name: "added and removed",
+			a: Pack{
+				{Name: "temp", Time: 1, Value: Float(21)},
+				{Name: "temp", Time: 2, Value: Float(22)},
+			},
+			b: Pack{
+				{Name: "temp", Time: 2, Value: Float(22)},
+				{Name: "temp", Time: 3, Unit: Celsius, Value: Float(23)},
+				{Name: "energy", Time: 4, BaseVersion: 10, Sum: Float(1.5)},
+			},
+			res: "- temp at 1: v=21\n" +
+				"+ temp at 3: u=Cel v=23\n" +
+				"+ energy at 4: bver=10 s=1.5",
+		},
+		{
+			name: "duplicates",
+			a: Pack{
+				{Name: "open", BoolValue: Bool(true)},
+			},
+			b: Pack{
+				{Name: "open", BoolValue: Bool(false)},
+				{Name: "open", BoolValue: Bool(true)},
+			},
+			res: "~ open at 0: vb true -> false\n" +
+				"+ open at 0: vb=true",
+		},
+	}
+	for _, tc := range tcs {
+		d := Diff(tc.a, tc.b)
+		if res := d.String(); res != tc.res {
+			t.Errorf("%s: diff should be\n%s\nnot\n%s", tc.name, tc.res, res)
+		}
+	}
+}
+
+func TestDiffChanges(t *testing.T) {
+	a := Pack{{Name: "temp", Value: Float(21)}}
+	b := Pack{{Name: "temp", Value: Float(22)}, {Name: "hum", Value: Float(50)}}
+	d := Diff(a, b)
+	if len(d) != 2 {
+		t.Fatalf("diff should have 2 changes not %d", len(d))
+	}
+	if d[0].Kind != Changed || d[0].Name != "temp" || d[0].Old == nil || d[0].New == nil ||
+		len(d[0].Fields) != 1 || d[0].Fields[0] != (FieldChange{Label: "v", Old: "21", New: "22"}) {
+		t.Errorf("first change should be a changed value, not %+v", d[0])
+	}
+	if d[1].Kind != Added || d[1].Name != "hum" || d[1].Old != nil || d[1].New == nil || *d[1].New.Value != 50 {
+		t.Errorf("second change should be an added record, not %+v", d[1])
+	}
+	if d := Diff(b, b); len(d) != 0 {
+		t.Errorf("a pack should not differ from itself: %s", d)
+	}
+}
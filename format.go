@@ -0,0 +1,163 @@
+package senml
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strings"
+)
+
+// Format is a SenML representation.
+type Format int
+
+// SenML representations, as defined in https://tools.ietf.org/html/rfc8428#section-12.
+const (
+	UnknownFormat Format = iota
+	JSON
+	CBOR
+	XML
+	EXI
+	// Sensor Streaming Measurement Lists
+	StreamJSON
+	StreamCBOR
+	StreamXML
+	StreamEXI
+)
+
+// Media types, as registered in https://tools.ietf.org/html/rfc8428#section-12.3.
+const (
+	MediaTypeJSON       = "application/senml+json"
+	MediaTypeCBOR       = "application/senml+cbor"
+	MediaTypeXML        = "application/senml+xml"
+	MediaTypeEXI        = "application/senml-exi"
+	MediaTypeStreamJSON = "application/sensml+json"
+	MediaTypeStreamCBOR = "application/sensml+cbor"
+	MediaTypeStreamXML  = "application/sensml+xml"
+	MediaTypeStreamEXI  = "application/sensml-exi"
+)
+
+// CoAP content formats, as registered in https://tools.ietf.org/html/rfc8428#section-12.3.
+const (
+	ContentFormatJSON       uint16 = 110
+	ContentFormatStreamJSON uint16 = 111
+	ContentFormatCBOR       uint16 = 112
+	ContentFormatStreamCBOR uint16 = 113
+	ContentFormatEXI        uint16 = 114
+	ContentFormatStreamEXI  uint16 = 115
+	ContentFormatXML        uint16 = 310
+	ContentFormatStreamXML  uint16 = 311
+)
+
+// ErrUnsupportedFormat is returned when encoding to or decoding from a format that is not implemented.
+var ErrUnsupportedFormat = errors.New("senml: unsupported format")
+
+type formatInfo struct {
+	name          string
+	mediaType     string
+	contentFormat uint16
+	extension     string
+}
+
+var formats = map[Format]formatInfo{
+	JSON:       {"json", MediaTypeJSON, ContentFormatJSON, ".senmlj"},
+	CBOR:       {"cbor", MediaTypeCBOR, ContentFormatCBOR, ".senmlc"},
+	XML:        {"xml", MediaTypeXML, ContentFormatXML, ".senmlx"},
+	EXI:        {"exi", MediaTypeEXI, ContentFormatEXI, ".senmle"},
+	StreamJSON: {"stream+json", MediaTypeStreamJSON, ContentFormatStreamJSON, ".sensmlj"},
+	StreamCBOR: {"stream+cbor", MediaTypeStreamCBOR, ContentFormatStreamCBOR, ".sensmlc"},
+	StreamXML:  {"stream+xml", MediaTypeStreamXML, ContentFormatStreamXML, ".sensmlx"},
+	StreamEXI:  {"stream-exi", MediaTypeStreamEXI, ContentFormatStreamEXI, ".sensmle"},
+}
+
+// String implements fmt.Stringer.
+func (f Format) String() string {
+	if fi, ok := formats[f]; ok {
+		return fi.name
+	}
+	return "unknown"
+}
+
+// MediaType returns the media type of the format, or an empty string for an unknown format.
+func (f Format) MediaType() string {
+	return formats[f].mediaType
+}
+
+// ContentFormat returns the CoAP content format of the format, or 0 for an unknown format.
+func (f Format) ContentFormat() uint16 {
+	return formats[f].contentFormat
+}
+
+// Extension returns the file extension (including the leading dot) of the format,
+// or an empty string for an unknown format.
+func (f Format) Extension() string {
+	return formats[f].extension
+}
+
+// FormatFromMediaType returns the format matching a media type. Media type parameters (e.g. "; charset=utf-8") are ignored.
+func FormatFromMediaType(mt string) (Format, bool) {
+	if i := strings.IndexByte(mt, ';'); i >= 0 {
+		mt = mt[:i]
+	}
+	mt = strings.ToLower(strings.TrimSpace(mt))
+	for f, fi := range formats {
+		if fi.mediaType == mt {
+			return f, true
+		}
+	}
+	return UnknownFormat, false
+}
+
+// FormatFromContentFormat returns the format matching a CoAP content format.
+func FormatFromContentFormat(cf uint16) (Format, bool) {
+	for f, fi := range formats {
+		if fi.contentFormat == cf {
+			return f, true
+		}
+	}
+	return UnknownFormat, false
+}
+
+// FormatFromExtension returns the format matching a file extension, with or without the leading dot.
+func FormatFromExtension(ext string) (Format, bool) {
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	for f, fi := range formats {
+		if fi.extension == ext {
+			return f, true
+		}
+	}
+	return UnknownFormat, false
+}
+
+// Marshal encodes a SenML Pack in the given format.
+// SenML and SenSML representations of a Pack are identical.
+func Marshal(f Format, p Pack) ([]byte, error) {
+	switch f {
+	case JSON, StreamJSON:
+		return json.Marshal(p)
+	case XML, StreamXML:
+		return xml.Marshal(p)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// Unmarshal decodes a SenML Pack encoded in the given format.
+func Unmarshal(f Format, data []byte) (Pack, error) {
+	p := Pack{}
+	var err error
+	switch f {
+	case JSON, StreamJSON:
+		err = json.Unmarshal(data, &p)
+	case XML, StreamXML:
+		err = xml.Unmarshal(data, &p)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,92 @@
+package senml
+
+import (
+	"testing"
+)
+
+func TestFormatLookup(t *testing.T) {
+	tcs := []struct {
+		format        Format
+		mediaType     string
+		contentFormat uint16
+		extension     string
+	}{
+		{JSON, "application/senml+json", 110, ".senmlj"},
+		{StreamJSON, "application/sensml+json", 111, ".sensmlj"},
+		{CBOR, "application/senml+cbor", 112, ".senmlc"},
+		{StreamCBOR, "application/sensml+cbor", 113, ".sensmlc"},
+		{EXI, "application/senml-exi", 114, ".senmle"},
+		{StreamEXI, "application/sensml-exi", 115, ".sensmle"},
+		{XML, "application/senml+xml", 310, ".senmlx"},
+		{StreamXML, "application/sensml+xml", 311, ".sensmlx"},
+	}
+	for _, tc := range tcs {
+		if tc.format.MediaType() != tc.mediaType {
+			t.Errorf("media type of %s should be %s not %s", tc.format, tc.mediaType, tc.format.MediaType())
+		}
+		if tc.format.ContentFormat() != tc.contentFormat {
+			t.Errorf("content format of %s should be %d not %d", tc.format, tc.contentFormat, tc.format.ContentFormat())
+		}
+		if tc.format.Extension() != tc.extension {
+			t.Errorf("extension of %s should be %s not %s", tc.format, tc.extension, tc.format.Extension())
+		}
+		if f, ok := FormatFromMediaType(tc.mediaType); !ok || f != tc.format {
+			t.Errorf("FormatFromMediaType(%s) should return %s not %s", tc.mediaType, tc.format, f)
+		}
+		if f, ok := FormatFromContentFormat(tc.contentFormat); !ok || f != tc.format {
+			t.Errorf("FormatFromContentFormat(%d) should return %s not %s", tc.contentFormat, tc.format, f)
+		}
+		if f, ok := FormatFromExtension(tc.extension); !ok || f != tc.format {
+			t.Errorf("FormatFromExtension(%s) should return %s not %s", tc.extension, tc.format, f)
+		}
+	}
+
+	if f, ok := FormatFromMediaType("Application/SenML+JSON; charset=utf-8"); !ok || f != JSON {
+		t.Errorf("FormatFromMediaType should ignore case and parameters")
+	}
+	if f, ok := FormatFromExtension("senmlx"); !ok || f != XML {
+		t.Errorf("FormatFromExtension should accept extensions without a dot")
+	}
+	if _, ok := FormatFromMediaType("application/json"); ok {
+		t.Errorf("FormatFromMediaType should not match application/json")
+	}
+	if _, ok := FormatFromContentFormat(50); ok {
+		t.Errorf("FormatFromContentFormat should not match 50")
+	}
+	if UnknownFormat.MediaType() != "" || UnknownFormat.String() != "unknown" {
+		t.Errorf("UnknownFormat should not have a media type")
+	}
+}
+
+func TestMarshalUnmarshal(t *testing.T) {
+	src := Pack{
+		{BaseName: "urn:dev:ow:10e2073a01080063:", Name: "temp", Unit: Celsius, Value: Float(23.1)},
+		{Name: "open", BoolValue: True},
+	}
+	for _, f := range []Format{JSON, StreamJSON, XML, StreamXML} {
+		enc, err := Marshal(f, src)
+		if err != nil {
+			t.Errorf("%s encoding of %+v returned an error : %s", f, src, err)
+			continue
+		}
+		dec, err := Unmarshal(f, enc)
+		if err != nil {
+			t.Errorf("%s decoding of %s returned an error : %s", f, enc, err)
+			continue
+		}
+		if !src.Equals(dec) {
+			t.Errorf("%s decoding of %s should be %+v not %+v", f, enc, src, dec)
+		}
+	}
+	for _, f := range []Format{UnknownFormat, CBOR, EXI} {
+		if _, err := Marshal(f, src); err != ErrUnsupportedFormat {
+			t.Errorf("%s encoding should return ErrUnsupportedFormat, not %v", f, err)
+		}
+		if _, err := Unmarshal(f, nil); err != ErrUnsupportedFormat {
+			t.Errorf("%s decoding should return ErrUnsupportedFormat, not %v", f, err)
+		}
+	}
+	if _, err := Unmarshal(JSON, []byte("{")); err == nil {
+		t.Errorf("decoding invalid JSON should return an error")
+	}
+}
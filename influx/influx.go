@@ -0,0 +1,335 @@
+// Package influx converts SenML packs to and from the InfluxDB line protocol,
+// as defined in https://docs.influxdata.com/influxdb/v1.8/write_protocols/line_protocol_reference/.
+//
+// Each record is converted to a point. The measurement and tags are derived from the record name by a Splitter,
+// the unit is stored in the "unit" tag, and the value is stored in a field named after its SenML label
+// ("v", "s", "vb", "vs" or "vd", the latter being base64 encoded).
+package influx
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/objenious/senml"
+)
+
+// UnitTag is the tag used to store the unit of records.
+const UnitTag = "unit"
+
+var (
+	// ErrInvalidLine is returned when decoding a malformed line.
+	ErrInvalidLine = errors.New("influx: invalid line")
+	// ErrUnknownField is returned when decoding a line with a field that does not match a SenML value.
+	ErrUnknownField = errors.New("influx: unknown field")
+	// ErrNoValue is returned when encoding a record without a value.
+	ErrNoValue = errors.New("influx: record without value")
+	// ErrNewline is returned when encoding a record with a newline in its measurement, tags or string value,
+	// as the line protocol does not support them.
+	ErrNewline = errors.New("influx: newline in a measurement, tag or string value")
+)
+
+// Splitter maps record names to measurements and tags.
+type Splitter interface {
+	// Split returns the measurement and the tags for a name.
+	Split(name string) (measurement string, tags map[string]string)
+	// Join returns the name for a measurement and its tags.
+	Join(measurement string, tags map[string]string) string
+}
+
+// NameSplitter uses the record name as the measurement, without tags.
+type NameSplitter struct{}
+
+// Split implements Splitter.
+func (NameSplitter) Split(name string) (string, map[string]string) {
+	return name, nil
+}
+
+// Join implements Splitter.
+func (NameSplitter) Join(measurement string, tags map[string]string) string {
+	return measurement
+}
+
+// SeparatorSplitter splits record names at the last occurrence of Separator.
+// The part after the separator is the measurement, and the part up to and including the separator is stored in the Tag tag.
+// e.g. "urn:dev:ow:10e2073a01080063:temp" is split into the "temp" measurement with a "urn:dev:ow:10e2073a01080063:" tag,
+// with ":" as separator.
+type SeparatorSplitter struct {
+	Separator string
+	Tag       string
+}
+
+// Split implements Splitter.
+func (s SeparatorSplitter) Split(name string) (string, map[string]string) {
+	i := strings.LastIndex(name, s.Separator)
+	if s.Separator == "" || i < 0 {
+		return name, nil
+	}
+	i += len(s.Separator)
+	return name[i:], map[string]string{s.Tag: name[:i]}
+}
+
+// Join implements Splitter.
+func (s SeparatorSplitter) Join(measurement string, tags map[string]string) string {
+	return tags[s.Tag] + measurement
+}
+
+// Marshal encodes a normalized pack to the line protocol. If s is nil, NameSplitter is used.
+// Records with a zero time are encoded without timestamp.
+// A *senml.NonFiniteError is returned for non-finite values, which the line protocol does not support.
+func Marshal(p senml.Pack, s Splitter) ([]byte, error) {
+	if s == nil {
+		s = NameSplitter{}
+	}
+	var buf bytes.Buffer
+	for i, r := range p {
+		measurement, split := s.Split(r.Name)
+		// the map returned by the splitter is not modified
+		tags := make(map[string]string, len(split)+1)
+		for k, v := range split {
+			tags[k] = v
+		}
+		if r.Unit != "" {
+			tags[UnitTag] = string(r.Unit)
+		}
+		if strings.Contains(measurement, "\n") || strings.Contains(r.StringValue, "\n") {
+			return nil, ErrNewline
+		}
+		buf.WriteString(escape(measurement, ", "))
+		keys := make([]string, 0, len(tags))
+		for k, v := range tags {
+			if strings.Contains(k, "\n") || strings.Contains(v, "\n") {
+				return nil, ErrNewline
+			}
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if tags[k] == "" {
+				continue
+			}
+			buf.WriteByte(',')
+			buf.WriteString(escape(k, ",= "))
+			buf.WriteByte('=')
+			buf.WriteString(escape(tags[k], ",= "))
+		}
+		buf.WriteByte(' ')
+		switch {
+		case r.Value != nil:
+			if math.IsNaN(*r.Value) || math.IsInf(*r.Value, 0) {
+				return nil, &senml.NonFiniteError{Record: i, Label: "v", Value: *r.Value}
+			}
+			buf.WriteString("v=")
+			buf.WriteString(strconv.FormatFloat(*r.Value, 'g', -1, 64))
+		case r.BoolValue != nil:
+			buf.WriteString("vb=")
+			buf.WriteString(strconv.FormatBool(*r.BoolValue))
+		case r.StringValue != "":
+			buf.WriteString("vs=")
+			buf.WriteString(`"` + escape(r.StringValue, `"\`) + `"`)
+		case r.DataValue != nil:
+			buf.WriteString(`vd="`)
+			buf.WriteString(base64.StdEncoding.EncodeToString(r.DataValue))
+			buf.WriteByte('"')
+		case r.Sum != nil:
+			if math.IsNaN(*r.Sum) || math.IsInf(*r.Sum, 0) {
+				return nil, &senml.NonFiniteError{Record: i, Label: "s", Value: *r.Sum}
+			}
+			buf.WriteString("s=")
+			buf.WriteString(strconv.FormatFloat(*r.Sum, 'g', -1, 64))
+		default:
+			return nil, ErrNoValue
+		}
+		if r.Time != 0 {
+			buf.WriteByte(' ')
+			buf.WriteString(strconv.FormatInt(r.GoTime().UnixNano(), 10))
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes points in the line protocol to a normalized pack. If s is nil, NameSplitter is used.
+// A record is created for each field.
+func Unmarshal(data []byte, s Splitter) (senml.Pack, error) {
+	if s == nil {
+		s = NameSplitter{}
+	}
+	p := senml.Pack{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		records, err := decodeLine(line, s)
+		if err != nil {
+			return nil, err
+		}
+		p = append(p, records...)
+	}
+	return p, nil
+}
+
+func decodeLine(line string, s Splitter) ([]senml.Record, error) {
+	sections := split(line, ' ', true)
+	if len(sections) < 2 || len(sections) > 3 {
+		return nil, ErrInvalidLine
+	}
+	key := split(sections[0], ',', false)
+	if key[0] == "" {
+		return nil, ErrInvalidLine
+	}
+	measurement := unescape(key[0])
+	tags := map[string]string{}
+	for _, kv := range key[1:] {
+		t := split(kv, '=', false)
+		if len(t) != 2 || t[0] == "" {
+			return nil, ErrInvalidLine
+		}
+		tags[unescape(t[0])] = unescape(t[1])
+	}
+	unit := senml.Unit(tags[UnitTag])
+	delete(tags, UnitTag)
+
+	var ts float64
+	if len(sections) == 3 {
+		ns, err := strconv.ParseInt(sections[2], 10, 64)
+		if err != nil {
+			return nil, ErrInvalidLine
+		}
+		ts = senml.Time(time.Unix(0, ns))
+	}
+
+	name := s.Join(measurement, tags)
+	fields := split(sections[1], ',', true)
+	records := make([]senml.Record, 0, len(fields))
+	for _, f := range fields {
+		kv := split(f, '=', true)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, ErrInvalidLine
+		}
+		v, err := parseValue(kv[1])
+		if err != nil {
+			return nil, err
+		}
+		r := senml.Record{Name: name, Unit: unit, Time: ts}
+		field := unescape(kv[0])
+		switch field {
+		case "v", "s":
+			f, ok := v.(float64)
+			if !ok {
+				return nil, ErrInvalidLine
+			}
+			if field == "v" {
+				r.Value = senml.Float(f)
+			} else {
+				r.Sum = senml.Float(f)
+			}
+		case "vb":
+			b, ok := v.(bool)
+			if !ok {
+				return nil, ErrInvalidLine
+			}
+			r.BoolValue = senml.Bool(b)
+		case "vs":
+			str, ok := v.(string)
+			if !ok {
+				return nil, ErrInvalidLine
+			}
+			r.StringValue = str
+		case "vd":
+			str, ok := v.(string)
+			if !ok {
+				return nil, ErrInvalidLine
+			}
+			if r.DataValue, err = base64.StdEncoding.DecodeString(str); err != nil {
+				return nil, ErrInvalidLine
+			}
+		default:
+			return nil, ErrUnknownField
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// parseValue parses a field value, returning a float64, a bool or a string.
+func parseValue(s string) (interface{}, error) {
+	switch {
+	case len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"':
+		return unescape(s[1 : len(s)-1]), nil
+	case s == "t" || s == "T" || s == "true" || s == "True" || s == "TRUE":
+		return true, nil
+	case s == "f" || s == "F" || s == "false" || s == "False" || s == "FALSE":
+		return false, nil
+	case strings.HasSuffix(s, "i"):
+		i, err := strconv.ParseInt(s[:len(s)-1], 10, 64)
+		if err != nil {
+			return nil, ErrInvalidLine
+		}
+		return float64(i), nil
+	case strings.HasSuffix(s, "u"):
+		u, err := strconv.ParseUint(s[:len(s)-1], 10, 64)
+		if err != nil {
+			return nil, ErrInvalidLine
+		}
+		return float64(u), nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, ErrInvalidLine
+	}
+	return f, nil
+}
+
+// split splits s at each unescaped sep. If quotes is true, separators within double quotes are ignored.
+func split(s string, sep byte, quotes bool) []string {
+	var parts []string
+	start := 0
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case quotes && s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func escape(s, chars string) string {
+	if !strings.ContainsAny(s, chars) {
+		return s
+	}
+	var buf bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(chars, s[i]) >= 0 {
+			buf.WriteByte('\\')
+		}
+		buf.WriteByte(s[i])
+	}
+	return buf.String()
+}
+
+func unescape(s string) string {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s
+	}
+	var buf bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(`,= "\`, s[i+1]) >= 0 {
+			i++
+		}
+		buf.WriteByte(s[i])
+	}
+	return buf.String()
+}
@@ -0,0 +1,145 @@
+package influx
+
+import (
+	"math"
+	"testing"
+
+	"github.com/objenious/senml"
+)
+
+func TestMarshal(t *testing.T) {
+	tcs := []struct {
+		src      senml.Pack
+		splitter Splitter
+		lines    string
+	}{
+		{
+			src: senml.Pack{
+				{Name: "urn:dev:ow:10e2073a01080063:temp", Unit: senml.Celsius, Time: 1.320067464e+09, Value: senml.Float(23.1)},
+				{Name: "urn:dev:ow:10e2073a01080063:open", Time: 1.320067464e+09, BoolValue: senml.True},
+				{Name: "urn:dev:ow:10e2073a01080063:label", Time: 1.320067464e+09, StringValue: `kitchen "north", 1\2`},
+				{Name: "urn:dev:ow:10e2073a01080063:raw", Time: 1.320067464e+09, DataValue: []byte{0xca, 0xfe}},
+				{Name: "urn:dev:ow:10e2073a01080063:energy", Unit: senml.Joule, Time: 1.5e+09, Sum: senml.Float(1200)},
+			},
+			splitter: SeparatorSplitter{Separator: ":", Tag: "device"},
+			lines: `temp,device=urn:dev:ow:10e2073a01080063:,unit=Cel v=23.1 1320067464000000000
+open,device=urn:dev:ow:10e2073a01080063: vb=true 1320067464000000000
+label,device=urn:dev:ow:10e2073a01080063: vs="kitchen \"north\", 1\\2" 1320067464000000000
+raw,device=urn:dev:ow:10e2073a01080063: vd="yv4=" 1320067464000000000
+energy,device=urn:dev:ow:10e2073a01080063:,unit=J s=1200 1500000000000000000
+`,
+		},
+		{
+			src: senml.Pack{
+				{Name: "room 1,temp", Unit: senml.Celsius, Time: 1.5000000001e+09, Value: senml.Float(20)},
+				{Name: "hum", Unit: senml.RelativeHumidity, Value: senml.Float(40)},
+			},
+			lines: `room\ 1\,temp,unit=Cel v=20 1500000000100000000
+hum,unit=%RH v=40
+`,
+		},
+	}
+	for _, tc := range tcs {
+		enc, err := Marshal(tc.src, tc.splitter)
+		if err != nil {
+			t.Errorf("encoding of %+v returned an error : %s", tc.src, err)
+		}
+		if string(enc) != tc.lines {
+			t.Errorf("encoding of %+v should be\n%s\nnot\n%s", tc.src, tc.lines, enc)
+		}
+		dec, err := Unmarshal([]byte(tc.lines), tc.splitter)
+		if err != nil {
+			t.Errorf("decoding of %s returned an error : %s", tc.lines, err)
+		}
+		if !tc.src.Equals(dec) {
+			t.Errorf("decoding of %s should be %+v not %+v", tc.lines, tc.src, dec)
+		}
+	}
+	if _, err := Marshal(senml.Pack{{Name: "foo"}}, nil); err != ErrNoValue {
+		t.Errorf("encoding a record without value should return ErrNoValue, not %v", err)
+	}
+	for _, p := range []senml.Pack{
+		{{Name: "label", StringValue: "line1\nline2"}},
+		{{Name: "temp\n", Value: senml.Float(1)}},
+		{{Name: "dev\n:temp", Value: senml.Float(1)}},
+	} {
+		if _, err := Marshal(p, SeparatorSplitter{Separator: ":", Tag: "device"}); err != ErrNewline {
+			t.Errorf("encoding of %+v should return ErrNewline, not %v", p, err)
+		}
+	}
+	p := senml.Pack{{Name: "foo", Value: senml.Float(1)}, {Name: "foo", Sum: senml.Float(math.Inf(-1))}}
+	_, err := Marshal(p, nil)
+	if ne, ok := err.(*senml.NonFiniteError); !ok || ne.Record != 1 || ne.Label != "s" {
+		t.Errorf("encoding of %+v should return a *senml.NonFiniteError for record 1, not %v", p, err)
+	}
+	if _, err := Marshal(senml.Pack{{Name: "foo", Value: senml.Float(math.NaN())}}, nil); err == nil {
+		t.Errorf("encoding of a NaN value should return an error")
+	}
+}
+
+// fixedSplitter returns the same tags for all names.
+type fixedSplitter map[string]string
+
+func (s fixedSplitter) Split(name string) (string, map[string]string) {
+	return name, s
+}
+
+func (s fixedSplitter) Join(measurement string, tags map[string]string) string {
+	return measurement
+}
+
+func TestMarshalSplitterTags(t *testing.T) {
+	s := fixedSplitter{"site": "paris"}
+	enc, err := Marshal(senml.Pack{{Name: "temp", Unit: senml.Celsius, Value: senml.Float(20)}}, s)
+	if exp := "temp,site=paris,unit=Cel v=20\n"; err != nil || string(enc) != exp {
+		t.Errorf("encoding should be %q not %q (%v)", exp, enc, err)
+	}
+	if len(s) != 1 {
+		t.Errorf("encoding should not modify the tags returned by the splitter, not %v", s)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	lines := `# comment
+
+temp,unit=Cel,room=kitchen v=21i,s=3u 1500000000000000000
+door vb=F,vs="a b=c"
+`
+	exp := senml.Pack{
+		{Name: "temp", Unit: senml.Celsius, Time: 1.5e+09, Value: senml.Float(21)},
+		{Name: "temp", Unit: senml.Celsius, Time: 1.5e+09, Sum: senml.Float(3)},
+		{Name: "door", BoolValue: senml.False},
+		{Name: "door", StringValue: "a b=c"},
+	}
+	dec, err := Unmarshal([]byte(lines), nil)
+	if err != nil {
+		t.Fatalf("decoding of %s returned an error : %s", lines, err)
+	}
+	if !exp.Equals(dec) {
+		t.Errorf("decoding of %s should be %+v not %+v", lines, exp, dec)
+	}
+
+	tcs := []struct {
+		line string
+		err  error
+	}{
+		{line: "temp", err: ErrInvalidLine},
+		{line: "temp v=1 2 3", err: ErrInvalidLine},
+		{line: ",unit=Cel v=1", err: ErrInvalidLine},
+		{line: "temp,unit v=1", err: ErrInvalidLine},
+		{line: "temp v=1 now", err: ErrInvalidLine},
+		{line: "temp v", err: ErrInvalidLine},
+		{line: "temp v=abc", err: ErrInvalidLine},
+		{line: "temp v=1x", err: ErrInvalidLine},
+		{line: `temp v="1"`, err: ErrInvalidLine},
+		{line: "temp vb=1", err: ErrInvalidLine},
+		{line: "temp vs=t", err: ErrInvalidLine},
+		{line: `temp vd="!"`, err: ErrInvalidLine},
+		{line: "temp value=1", err: ErrUnknownField},
+	}
+	for _, tc := range tcs {
+		if _, err := Unmarshal([]byte(tc.line), nil); err != tc.err {
+			t.Errorf("decoding of %s should return %v, not %v", tc.line, tc.err, err)
+		}
+	}
+}
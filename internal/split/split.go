@@ -0,0 +1,42 @@
+// Package split splits packs, for the sub-packages converting packs to other data models.
+package split
+
+import "github.com/objenious/senml"
+
+// ByBaseName splits the pack into packs sharing the same base name, in order of first appearance.
+// The base fields in effect are copied to each record, so that each pack can be resolved on its own.
+func ByBaseName(p senml.Pack) []senml.Pack {
+	var res []senml.Pack
+	idx := map[string]int{}
+	var base senml.Record
+	for _, r := range p {
+		if r.BaseName != "" {
+			base.BaseName = r.BaseName
+		}
+		if r.BaseTime != 0 {
+			base.BaseTime = r.BaseTime
+		}
+		if r.BaseUnit != "" {
+			base.BaseUnit = r.BaseUnit
+		}
+		if r.BaseValue != nil {
+			base.BaseValue = r.BaseValue
+		}
+		if r.BaseSum != nil {
+			base.BaseSum = r.BaseSum
+		}
+		if r.BaseVersion != 0 {
+			base.BaseVersion = r.BaseVersion
+		}
+		r.BaseName, r.BaseTime, r.BaseUnit, r.BaseValue, r.BaseSum, r.BaseVersion =
+			base.BaseName, base.BaseTime, base.BaseUnit, base.BaseValue, base.BaseSum, base.BaseVersion
+		i, found := idx[base.BaseName]
+		if !found {
+			i = len(res)
+			idx[base.BaseName] = i
+			res = append(res, nil)
+		}
+		res[i] = append(res[i], r)
+	}
+	return res
+}
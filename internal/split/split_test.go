@@ -0,0 +1,33 @@
+package split
+
+import (
+	"testing"
+
+	"github.com/objenious/senml"
+)
+
+func TestByBaseName(t *testing.T) {
+	src := senml.Pack{
+		{BaseName: "foo:", BaseTime: 10, BaseUnit: senml.Celsius, Name: "temp", Value: senml.Float(20)},
+		{BaseName: "bar:", Name: "temp", Value: senml.Float(21)},
+		{BaseName: "foo:", BaseValue: senml.Float(1), Name: "hum", Unit: senml.RelativeHumidity, Value: senml.Float(40)},
+	}
+	split := ByBaseName(src)
+	exp := []senml.Pack{
+		{
+			{BaseName: "foo:", BaseTime: 10, BaseUnit: senml.Celsius, Name: "temp", Value: senml.Float(20)},
+			{BaseName: "foo:", BaseTime: 10, BaseUnit: senml.Celsius, BaseValue: senml.Float(1), Name: "hum", Unit: senml.RelativeHumidity, Value: senml.Float(40)},
+		},
+		{
+			{BaseName: "bar:", BaseTime: 10, BaseUnit: senml.Celsius, Name: "temp", Value: senml.Float(21)},
+		},
+	}
+	if len(split) != len(exp) {
+		t.Fatalf("ByBaseName of %+v should return %d packs, not %d", src, len(exp), len(split))
+	}
+	for i := range exp {
+		if !exp[i].Equals(split[i]) {
+			t.Errorf("ByBaseName of %+v should return %+v not %+v", src, exp[i], split[i])
+		}
+	}
+}
@@ -0,0 +1,909 @@
+package senml
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// The JSON encoding and decoding of records and packs are hand-written, to avoid reflection
+// and the allocation of each pointer field : the output is identical to the output of encoding/json
+// of the Go version in use with the struct tags of Record, and data values are encoded as base64url.
+
+// ErrInvalidJSON is returned when decoding malformed JSON.
+var ErrInvalidJSON = errors.New("senml: invalid JSON")
+
+var (
+	floatType  = reflect.TypeOf(float64(0))
+	intType    = reflect.TypeOf(int(0))
+	stringType = reflect.TypeOf("")
+	bytesType  = reflect.TypeOf([]byte(nil))
+	boolType   = reflect.TypeOf(false)
+	recordType = reflect.TypeOf(Record{})
+	packType   = reflect.TypeOf(Pack{})
+)
+
+// JSONOptions define how packs are encoded to and decoded from JSON.
+type JSONOptions struct {
+	// NonFinite is the policy for non-finite numbers, which can not be represented as JSON numbers.
+	NonFinite NonFinitePolicy
+	// AutoVersion removes the base versions of the records, as the labels of Record are all defined
+	// by the default version.
+	AutoVersion bool
+}
+
+// EncodeJSON encodes a pack to JSON. json.Marshal(p) is equivalent to EncodeJSON(p, JSONOptions{}).
+func EncodeJSON(p Pack, opts JSONOptions) ([]byte, error) {
+	if opts.AutoVersion {
+		p = p.withoutVersions()
+	}
+	return p.appendJSON(nil, opts.NonFinite)
+}
+
+// DecodeJSON decodes a JSON encoded pack. json.Unmarshal(data, &p) is equivalent to DecodeJSON(data, JSONOptions{}).
+// As JSON numbers are finite, only the StringNonFinite policy changes decoding.
+func DecodeJSON(data []byte, opts JSONOptions) (Pack, error) {
+	d := jsonDecoder{data: data, nonFinite: opts.NonFinite}
+	var p Pack
+	if err := d.pack(&p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// MarshalJSON implements json.Marshaler.
+// A *NonFiniteError is returned if a record holds a non-finite number.
+func (p Pack) MarshalJSON() ([]byte, error) {
+	return p.appendJSON(nil, RejectNonFinite)
+}
+
+// AppendJSON appends the JSON encoding of the pack to b.
+// Reusing b across calls makes encoding allocation free.
+func (p Pack) AppendJSON(b []byte) ([]byte, error) {
+	return p.appendJSON(b, RejectNonFinite)
+}
+
+func (p Pack) appendJSON(b []byte, nonFinite NonFinitePolicy) ([]byte, error) {
+	if p == nil {
+		return append(b, "null"...), nil
+	}
+	b = append(b, '[')
+	first := true
+	for i := range p {
+		keep, err := p[i].checkFinite(i, nonFinite)
+		if err != nil {
+			return nil, err
+		}
+		if !keep {
+			continue
+		}
+		if !first {
+			b = append(b, ',')
+		}
+		first = false
+		if b, err = p[i].appendJSON(b, nonFinite); err != nil {
+			return nil, err
+		}
+	}
+	return append(b, ']'), nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r Record) MarshalJSON() ([]byte, error) {
+	return r.AppendJSON(nil)
+}
+
+// AppendJSON appends the JSON encoding of the record to b.
+// A *NonFiniteError is returned if the record holds a non-finite number.
+func (r *Record) AppendJSON(b []byte) ([]byte, error) {
+	return r.appendJSON(b, RejectNonFinite)
+}
+
+func (r *Record) appendJSON(b []byte, nonFinite NonFinitePolicy) ([]byte, error) {
+	e := jsonEncoder{b: append(b, '{'), first: true, nonFinite: nonFinite}
+	if r.BaseName != "" {
+		e.string("bn", r.BaseName)
+	}
+	if r.BaseTime != 0 {
+		e.float("bt", r.BaseTime)
+	}
+	if r.BaseUnit != "" {
+		e.string("bu", string(r.BaseUnit))
+	}
+	if r.BaseValue != nil {
+		e.float("bv", *r.BaseValue)
+	}
+	if r.BaseSum != nil {
+		e.float("bs", *r.BaseSum)
+	}
+	if r.BaseVersion != 0 {
+		e.key("bver")
+		e.b = strconv.AppendInt(e.b, int64(r.BaseVersion), 10)
+	}
+	if r.Name != "" {
+		e.string("n", r.Name)
+	}
+	if r.Unit != "" {
+		e.string("u", string(r.Unit))
+	}
+	if r.Time != 0 {
+		e.float("t", r.Time)
+	}
+	if r.UpdateTime != 0 {
+		e.float("ut", r.UpdateTime)
+	}
+	if r.Value != nil {
+		e.float("v", *r.Value)
+	}
+	if r.StringValue != "" {
+		e.string("vs", r.StringValue)
+	}
+	if len(r.DataValue) > 0 {
+		e.key("vd")
+		e.b = append(e.b, '"')
+		n := len(e.b)
+		l := base64.RawURLEncoding.EncodedLen(len(r.DataValue))
+		for cap(e.b)-n < l+1 {
+			e.b = append(e.b[:cap(e.b)], 0)
+		}
+		e.b = e.b[:n+l]
+		base64.RawURLEncoding.Encode(e.b[n:], r.DataValue)
+		e.b = append(e.b, '"')
+	}
+	if r.BoolValue != nil {
+		e.key("vb")
+		e.b = strconv.AppendBool(e.b, *r.BoolValue)
+	}
+	if r.Sum != nil {
+		e.float("s", *r.Sum)
+	}
+	if e.err != nil {
+		return nil, e.err
+	}
+	return append(e.b, '}'), nil
+}
+
+type jsonEncoder struct {
+	b         []byte
+	first     bool
+	err       error
+	nonFinite NonFinitePolicy
+}
+
+func (e *jsonEncoder) key(k string) {
+	if !e.first {
+		e.b = append(e.b, ',')
+	}
+	e.first = false
+	e.b = append(e.b, '"')
+	e.b = append(e.b, k...)
+	e.b = append(e.b, '"', ':')
+}
+
+func (e *jsonEncoder) string(k, s string) {
+	e.key(k)
+	e.b = appendJSONString(e.b, s)
+}
+
+// float encodes a number like encoding/json does. Non-finite numbers are encoded according to the policy,
+// times having been checked before.
+func (e *jsonEncoder) float(k string, f float64) {
+	if !isFinite(f) {
+		switch e.nonFinite {
+		case NullNonFinite:
+			e.key(k)
+			e.b = append(e.b, "null"...)
+		case StringNonFinite:
+			e.key(k)
+			e.b = append(e.b, '"')
+			e.b = append(e.b, jsonNonFinite(f)...)
+			e.b = append(e.b, '"')
+		default:
+			if e.err == nil {
+				e.err = &NonFiniteError{Record: -1, Label: k, Value: f}
+			}
+		}
+		return
+	}
+	e.key(k)
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	e.b = strconv.AppendFloat(e.b, f, format, -1, 64)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		if n := len(e.b); n >= 4 && e.b[n-4] == 'e' && e.b[n-3] == '-' && e.b[n-2] == '0' {
+			e.b[n-2] = e.b[n-1]
+			e.b = e.b[:n-1]
+		}
+	}
+}
+
+// jsonNonFinite returns the string encoding of a non-finite number, as used by JavaScript.
+func jsonNonFinite(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	return "NaN"
+}
+
+const hex = "0123456789abcdef"
+
+// jsonShortEscapes is true if encoding/json escapes backspace and form feed as \b and \f (since Go 1.22),
+// rather than \u0008 and \u000c.
+var jsonShortEscapes = func() bool {
+	b, _ := json.Marshal("\b")
+	return string(b) == `"\b"`
+}()
+
+// appendJSONString appends a quoted string, escaped like encoding/json does.
+func appendJSONString(b []byte, s string) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			case '\b', '\f':
+				switch {
+				case !jsonShortEscapes:
+					b = append(b, '\\', 'u', '0', '0', '0', hex[c])
+				case c == '\b':
+					b = append(b, '\\', 'b')
+				default:
+					b = append(b, '\\', 'f')
+				}
+			default:
+				b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 and U+2029 are not valid in JavaScript strings
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hex[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+// The storage of p is reused, and the pointer fields of all records are allocated together.
+// A *VersionError is returned if the base version of a record is higher than Version.
+func (p *Pack) UnmarshalJSON(data []byte) error {
+	d := jsonDecoder{data: data}
+	return d.pack(p)
+}
+
+// pack decodes a JSON array to p.
+func (d *jsonDecoder) pack(p *Pack) error {
+	if d.null() {
+		return d.end()
+	}
+	if !d.consume('[') {
+		return d.typeError("", packType)
+	}
+	n := (*p)[:0]
+	if !d.consume(']') {
+		for {
+			// the strings of the previous content are reused when unchanged
+			var old Record
+			if len(n) < cap(n) {
+				old = n[:len(n)+1][len(n)]
+			}
+			n = append(n, Record{})
+			if err := d.record(&n[len(n)-1], &old); err != nil {
+				return err
+			}
+			if err := n[len(n)-1].checkVersion(len(n) - 1); err != nil {
+				return err
+			}
+			if d.consume(',') {
+				continue
+			}
+			if d.consume(']') {
+				break
+			}
+			return ErrInvalidJSON
+		}
+	}
+	if err := d.end(); err != nil {
+		return err
+	}
+	*p = n
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+// A *VersionError is returned if the base version of the record is higher than Version.
+func (r *Record) UnmarshalJSON(data []byte) error {
+	d := jsonDecoder{data: data}
+	old := *r
+	if err := d.record(r, &old); err != nil {
+		return err
+	}
+	if err := r.checkVersion(-1); err != nil {
+		return err
+	}
+	return d.end()
+}
+
+// jsonDecoder decodes JSON records, allocating pointer fields in chunks.
+type jsonDecoder struct {
+	data   []byte
+	pos    int
+	floats []float64
+	bools  []bool
+	// number of allocated pointers
+	nfloats, nbools int
+	// reuse the storage of data values
+	reuse bool
+	// policy for non-finite values : StringNonFinite accepts strings
+	nonFinite NonFinitePolicy
+}
+
+func (d *jsonDecoder) skipSpace() {
+	for d.pos < len(d.data) {
+		switch d.data[d.pos] {
+		case ' ', '\t', '\n', '\r':
+			d.pos++
+		default:
+			return
+		}
+	}
+}
+
+// consume skips spaces, and c if it is the next byte.
+func (d *jsonDecoder) consume(c byte) bool {
+	d.skipSpace()
+	if d.pos < len(d.data) && d.data[d.pos] == c {
+		d.pos++
+		return true
+	}
+	return false
+}
+
+func (d *jsonDecoder) literal(s string) bool {
+	d.skipSpace()
+	if len(d.data)-d.pos >= len(s) && string(d.data[d.pos:d.pos+len(s)]) == s {
+		d.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (d *jsonDecoder) null() bool {
+	return d.literal("null")
+}
+
+func (d *jsonDecoder) end() error {
+	d.skipSpace()
+	if d.pos != len(d.data) {
+		return ErrInvalidJSON
+	}
+	return nil
+}
+
+func (d *jsonDecoder) peek() byte {
+	d.skipSpace()
+	if d.pos < len(d.data) {
+		return d.data[d.pos]
+	}
+	return 0
+}
+
+func (d *jsonDecoder) typeError(field string, t reflect.Type) error {
+	var value string
+	switch d.peek() {
+	case '"':
+		value = "string"
+	case '{':
+		value = "object"
+	case '[':
+		value = "array"
+	case 't', 'f':
+		value = "bool"
+	case 'n':
+		value = "null"
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		value = "number"
+	default:
+		return ErrInvalidJSON
+	}
+	return &json.UnmarshalTypeError{Value: value, Type: t, Offset: int64(d.pos), Field: field}
+}
+
+func (d *jsonDecoder) newFloat(f float64) *float64 {
+	if len(d.floats) == cap(d.floats) {
+		d.floats = make([]float64, 0, 2*cap(d.floats)+8)
+	}
+	d.floats = append(d.floats, f)
+	d.nfloats++
+	return &d.floats[len(d.floats)-1]
+}
+
+func (d *jsonDecoder) newBool(b bool) *bool {
+	if len(d.bools) == cap(d.bools) {
+		d.bools = make([]bool, 0, 2*cap(d.bools)+8)
+	}
+	d.bools = append(d.bools, b)
+	d.nbools++
+	return &d.bools[len(d.bools)-1]
+}
+
+// record decodes a JSON object to r. Unknown labels are ignored, and null values leave fields unchanged,
+// except pointer and data fields which are set to nil. Strings equal to the strings of old are not allocated.
+func (d *jsonDecoder) record(r *Record, old *Record) error {
+	if d.null() {
+		return nil
+	}
+	if !d.consume('{') {
+		return d.typeError("", recordType)
+	}
+	if d.consume('}') {
+		return nil
+	}
+	for {
+		if d.peek() != '"' {
+			return ErrInvalidJSON
+		}
+		key, err := d.stringBytes()
+		if err != nil {
+			return err
+		}
+		if !d.consume(':') {
+			return ErrInvalidJSON
+		}
+		switch string(key) {
+		case "bn":
+			err = d.string("bn", &r.BaseName, old.BaseName)
+		case "bt":
+			err = d.float("bt", &r.BaseTime)
+		case "bu":
+			err = d.unit("bu", &r.BaseUnit, old.BaseUnit)
+		case "bv":
+			err = d.floatPtr("bv", &r.BaseValue)
+		case "bs":
+			err = d.floatPtr("bs", &r.BaseSum)
+		case "bver":
+			err = d.int("bver", &r.BaseVersion)
+		case "n":
+			err = d.string("n", &r.Name, old.Name)
+		case "u":
+			err = d.unit("u", &r.Unit, old.Unit)
+		case "t":
+			err = d.float("t", &r.Time)
+		case "ut":
+			err = d.float("ut", &r.UpdateTime)
+		case "v":
+			err = d.floatPtr("v", &r.Value)
+		case "vs":
+			err = d.string("vs", &r.StringValue, old.StringValue)
+		case "vd":
+			err = d.data64("vd", &r.DataValue, old.DataValue)
+		case "vb":
+			err = d.boolPtr("vb", &r.BoolValue)
+		case "s":
+			err = d.floatPtr("s", &r.Sum)
+		default:
+			err = d.skip()
+		}
+		if err != nil {
+			return err
+		}
+		if d.consume(',') {
+			continue
+		}
+		if d.consume('}') {
+			return nil
+		}
+		return ErrInvalidJSON
+	}
+}
+
+func (d *jsonDecoder) string(field string, s *string, old string) error {
+	if d.null() {
+		return nil
+	}
+	if d.peek() != '"' {
+		return d.typeError(field, stringType)
+	}
+	b, err := d.stringBytes()
+	if err != nil {
+		return err
+	}
+	if string(b) == old {
+		*s = old
+	} else {
+		*s = string(b)
+	}
+	return nil
+}
+
+func (d *jsonDecoder) unit(field string, u *Unit, old Unit) error {
+	s := string(*u)
+	if err := d.string(field, &s, string(old)); err != nil {
+		return err
+	}
+	*u = Unit(s)
+	return nil
+}
+
+func (d *jsonDecoder) number(field string, t reflect.Type) ([]byte, error) {
+	c := d.peek()
+	if c != '-' && (c < '0' || c > '9') {
+		return nil, d.typeError(field, t)
+	}
+	start := d.pos
+	i := d.pos
+	digits := func() int {
+		n := 0
+		for i < len(d.data) && d.data[i] >= '0' && d.data[i] <= '9' {
+			i++
+			n++
+		}
+		return n
+	}
+	if i < len(d.data) && d.data[i] == '-' {
+		i++
+	}
+	intStart := i
+	if digits() == 0 || (d.data[intStart] == '0' && i-intStart > 1) {
+		return nil, ErrInvalidJSON
+	}
+	if i < len(d.data) && d.data[i] == '.' {
+		i++
+		if digits() == 0 {
+			return nil, ErrInvalidJSON
+		}
+	}
+	if i < len(d.data) && (d.data[i] == 'e' || d.data[i] == 'E') {
+		i++
+		if i < len(d.data) && (d.data[i] == '+' || d.data[i] == '-') {
+			i++
+		}
+		if digits() == 0 {
+			return nil, ErrInvalidJSON
+		}
+	}
+	d.pos = i
+	return d.data[start:i], nil
+}
+
+func (d *jsonDecoder) parseFloat(field string) (float64, error) {
+	start := d.pos
+	b, err := d.number(field, floatType)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(string(b), 64)
+	if err != nil {
+		return 0, &json.UnmarshalTypeError{Value: "number " + string(b), Type: floatType, Offset: int64(start), Field: field}
+	}
+	return f, nil
+}
+
+func (d *jsonDecoder) float(field string, f *float64) error {
+	if d.null() {
+		return nil
+	}
+	v, err := d.parseFloat(field)
+	if err != nil {
+		return err
+	}
+	*f = v
+	return nil
+}
+
+func (d *jsonDecoder) floatPtr(field string, f **float64) error {
+	if d.null() {
+		*f = nil
+		return nil
+	}
+	if d.nonFinite == StringNonFinite && d.peek() == '"' {
+		start := d.pos
+		s, err := d.stringBytes()
+		if err != nil {
+			return err
+		}
+		switch string(s) {
+		case "NaN":
+			*f = d.newFloat(math.NaN())
+		case "Infinity":
+			*f = d.newFloat(math.Inf(1))
+		case "-Infinity":
+			*f = d.newFloat(math.Inf(-1))
+		default:
+			return &json.UnmarshalTypeError{Value: "string", Type: floatType, Offset: int64(start), Field: field}
+		}
+		return nil
+	}
+	v, err := d.parseFloat(field)
+	if err != nil {
+		return err
+	}
+	*f = d.newFloat(v)
+	return nil
+}
+
+func (d *jsonDecoder) int(field string, n *int) error {
+	if d.null() {
+		return nil
+	}
+	start := d.pos
+	b, err := d.number(field, intType)
+	if err != nil {
+		return err
+	}
+	v, err := strconv.Atoi(string(b))
+	if err != nil {
+		return &json.UnmarshalTypeError{Value: "number " + string(b), Type: intType, Offset: int64(start), Field: field}
+	}
+	*n = v
+	return nil
+}
+
+func (d *jsonDecoder) boolPtr(field string, b **bool) error {
+	switch {
+	case d.null():
+		*b = nil
+	case d.literal("true"):
+		*b = d.newBool(true)
+	case d.literal("false"):
+		*b = d.newBool(false)
+	default:
+		return d.typeError(field, boolType)
+	}
+	return nil
+}
+
+func (d *jsonDecoder) data64(field string, b *[]byte, old []byte) error {
+	if d.null() {
+		*b = nil
+		return nil
+	}
+	if d.peek() != '"' {
+		return d.typeError(field, bytesType)
+	}
+	s, err := d.stringBytes()
+	if err != nil {
+		return err
+	}
+	var v []byte
+	if l := base64.RawURLEncoding.DecodedLen(len(s)); d.reuse && cap(old) >= l {
+		v = old[:l]
+	} else {
+		v = make([]byte, l)
+	}
+	n, err := base64.RawURLEncoding.Decode(v, s)
+	if err != nil {
+		// padded or standard base64
+		if v, err = DecodeDataValue(string(s)); err != nil {
+			return err
+		}
+		*b = v
+		return nil
+	}
+	*b = v[:n]
+	return nil
+}
+
+// stringBytes decodes a string. The returned slice is only valid until the next call.
+func (d *jsonDecoder) stringBytes() ([]byte, error) {
+	d.skipSpace()
+	if d.pos >= len(d.data) || d.data[d.pos] != '"' {
+		return nil, ErrInvalidJSON
+	}
+	start := d.pos + 1
+	for i := start; i < len(d.data); i++ {
+		switch c := d.data[i]; {
+		case c == '"':
+			d.pos = i + 1
+			s := d.data[start:i]
+			if !utf8.Valid(s) {
+				return unquote(s)
+			}
+			return s, nil
+		case c == '\\':
+			// slow path
+			end := i
+			for end < len(d.data) && d.data[end] != '"' {
+				if d.data[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(d.data) {
+				return nil, ErrInvalidJSON
+			}
+			d.pos = end + 1
+			return unquote(d.data[start:end])
+		case c < 0x20:
+			return nil, ErrInvalidJSON
+		}
+	}
+	return nil, ErrInvalidJSON
+}
+
+// unquote decodes the escape sequences of a string, and replaces invalid UTF-8 with U+FFFD.
+func unquote(s []byte) ([]byte, error) {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\':
+			if i+1 >= len(s) {
+				return nil, ErrInvalidJSON
+			}
+			switch s[i+1] {
+			case '"', '\\', '/':
+				b = append(b, s[i+1])
+			case 'b':
+				b = append(b, '\b')
+			case 'f':
+				b = append(b, '\f')
+			case 'n':
+				b = append(b, '\n')
+			case 'r':
+				b = append(b, '\r')
+			case 't':
+				b = append(b, '\t')
+			case 'u':
+				r, ok := hex4(s[i+2:])
+				if !ok {
+					return nil, ErrInvalidJSON
+				}
+				i += 6
+				if utf16.IsSurrogate(r) {
+					r2, ok := rune(-1), false
+					if i+1 < len(s) && s[i] == '\\' && s[i+1] == 'u' {
+						r2, ok = hex4(s[i+2:])
+					}
+					if dec := utf16.DecodeRune(r, r2); ok && dec != utf8.RuneError {
+						i += 6
+						r = dec
+					} else {
+						r = utf8.RuneError
+					}
+				}
+				b = append(b, string(r)...)
+				continue
+			default:
+				return nil, ErrInvalidJSON
+			}
+			i += 2
+		case c < 0x20:
+			return nil, ErrInvalidJSON
+		case c < utf8.RuneSelf:
+			b = append(b, c)
+			i++
+		default:
+			r, size := utf8.DecodeRune(s[i:])
+			i += size
+			b = append(b, string(r)...)
+		}
+	}
+	return b, nil
+}
+
+func hex4(s []byte) (rune, bool) {
+	if len(s) < 4 {
+		return 0, false
+	}
+	var r rune
+	for _, c := range s[:4] {
+		switch {
+		case c >= '0' && c <= '9':
+			c -= '0'
+		case c >= 'a' && c <= 'f':
+			c = c - 'a' + 10
+		case c >= 'A' && c <= 'F':
+			c = c - 'A' + 10
+		default:
+			return 0, false
+		}
+		r = r*16 + rune(c)
+	}
+	return r, true
+}
+
+// skip skips a value.
+func (d *jsonDecoder) skip() error {
+	switch d.peek() {
+	case '"':
+		_, err := d.stringBytes()
+		return err
+	case '{':
+		d.pos++
+		if d.consume('}') {
+			return nil
+		}
+		for {
+			if _, err := d.stringBytes(); err != nil {
+				return err
+			}
+			if !d.consume(':') {
+				return ErrInvalidJSON
+			}
+			if err := d.skip(); err != nil {
+				return err
+			}
+			if d.consume(',') {
+				continue
+			}
+			if d.consume('}') {
+				return nil
+			}
+			return ErrInvalidJSON
+		}
+	case '[':
+		d.pos++
+		if d.consume(']') {
+			return nil
+		}
+		for {
+			if err := d.skip(); err != nil {
+				return err
+			}
+			if d.consume(',') {
+				continue
+			}
+			if d.consume(']') {
+				return nil
+			}
+			return ErrInvalidJSON
+		}
+	case 't':
+		if d.literal("true") {
+			return nil
+		}
+	case 'f':
+		if d.literal("false") {
+			return nil
+		}
+	case 'n':
+		if d.literal("null") {
+			return nil
+		}
+	default:
+		_, err := d.number("", floatType)
+		return err
+	}
+	return ErrInvalidJSON
+}
@@ -0,0 +1,164 @@
+package senml
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// reflectRecord is encoded by encoding/json with reflection.
+type reflectRecord Record
+
+var jsonTestPack = Pack{
+	{BaseName: "urn:dev:ow:10e2073a01080063:", BaseTime: 1.320067464e+09, BaseUnit: Celsius, BaseValue: Float(1), BaseSum: Float(-2.5), BaseVersion: 10, Name: "temp", Value: Float(23.1)},
+	{Name: "energy", Unit: Joule, Time: -60, UpdateTime: 300, Value: Float(0), Sum: Float(1200)},
+	{Name: "small", Time: 1e-7, Value: Float(-1.5e-10)},
+	{Name: "large", Time: 1e21, Value: Float(123456789e20)},
+	{Name: "open", BoolValue: False},
+	{Name: "label", StringValue: "\"quoted\" \\ <tag> & \n\r\t\b\f\x01 é    \xff"},
+	{BaseVersion: -1},
+}
+
+func TestMarshalJSON(t *testing.T) {
+	for _, r := range jsonTestPack {
+		exp, _ := json.Marshal(reflectRecord(r))
+		enc, err := json.Marshal(r)
+		if err != nil || !bytes.Equal(enc, exp) {
+			t.Errorf("JSON encoding of %+v should be %s not %s (%v)", r, exp, enc, err)
+		}
+	}
+	if enc, err := json.Marshal(Pack(nil)); err != nil || string(enc) != "null" {
+		t.Errorf("JSON encoding of a nil pack should be null not %s (%v)", enc, err)
+	}
+	if enc, err := json.Marshal(Pack{}); err != nil || string(enc) != "[]" {
+		t.Errorf("JSON encoding of an empty pack should be [] not %s (%v)", enc, err)
+	}
+	if _, err := json.Marshal(Record{Value: Float(math.NaN())}); err == nil {
+		t.Errorf("JSON encoding of NaN should return an error")
+	}
+
+	b := make([]byte, 0, 4096)
+	allocs := testing.AllocsPerRun(100, func() {
+		b, _ = jsonTestPack.AppendJSON(b[:0])
+	})
+	if allocs != 0 {
+		t.Errorf("JSON encoding with AppendJSON should not allocate, not allocate %v times", allocs)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	js, _ := json.Marshal(jsonTestPack)
+	tcs := []string{
+		string(js),
+		` [ { "n" : "foo" , "v" : 1 , "vb" : true , "x" : { "a" : [ 1 , "b" , null , true , false , { } , [ ] ] } } , { } , null ] `,
+		`[{"n":"a\"b\\c\/d\b\f\n\r\té😀\ud800x\udc00A"}]`,
+		"[{\"n\":\"invalid \xff utf8\",\"vs\":\"\\u0041\"}]",
+		`[{"n":null,"v":null,"bt":null,"bver":null,"vb":null,"vd":null,"vs":"a"}]`,
+		`[{"bver":9,"t":-0.5e+3,"ut":1E2,"s":0}]`,
+		`[]`,
+	}
+	for _, tc := range tcs {
+		var exp []reflectRecord
+		if err := json.Unmarshal([]byte(tc), &exp); err != nil {
+			t.Fatalf("reflection JSON decoding of %s returned an error : %s", tc, err)
+		}
+		var dec Pack
+		if err := json.Unmarshal([]byte(tc), &dec); err != nil {
+			t.Errorf("JSON decoding of %s returned an error : %s", tc, err)
+			continue
+		}
+		if len(dec) != len(exp) {
+			t.Errorf("JSON decoding of %s should be %+v not %+v", tc, exp, dec)
+			continue
+		}
+		for i := range exp {
+			if !reflect.DeepEqual(Record(exp[i]), dec[i]) {
+				t.Errorf("JSON decoding of %s should be %+v not %+v", tc, exp, dec)
+				break
+			}
+		}
+	}
+
+	// storage is reused
+	dec := make(Pack, 0, 10)
+	if err := json.Unmarshal([]byte(`[{"n":"foo"}]`), &dec); err != nil || cap(dec) != 10 || dec[0].Name != "foo" {
+		t.Errorf("JSON decoding should reuse the storage of the pack")
+	}
+	var r Record
+	if err := json.Unmarshal([]byte(`{"n":"foo","vd":"-_8="}`), &r); err != nil || r.Name != "foo" || !bytes.Equal(r.DataValue, []byte{0xfb, 0xff}) {
+		t.Errorf("JSON decoding of a record returned %+v (%v)", r, err)
+	}
+}
+
+func TestUnmarshalJSONErrors(t *testing.T) {
+	for _, tc := range []string{``, `[`, `[{]`, `[{"n"}]`, `[{"n":"foo"]`, `[{"n":"foo",}]`, `[{"n":"foo}]`, `[{"v":01}]`, `[{"v":1.}]`, `[{"v":1e}]`, `[{"v":-}]`, `[{"n":"\x}]`, `[{"n":"\u12"}]`, "[{\"n\":\"\x01\"}]", `[{"x":tru}]`, `[{"x":[1,]}]`, `[{"x":{"a"}}]`, `[] x`, `[{"vb":`, `[{}`} {
+		var p Pack
+		if err := p.UnmarshalJSON([]byte(tc)); err != ErrInvalidJSON {
+			t.Errorf("JSON decoding of %s should return ErrInvalidJSON, not %v", tc, err)
+		}
+	}
+	for _, tc := range []string{`{}`, `[1]`, `[{"n":1}]`, `[{"v":"1"}]`, `[{"v":1e400}]`, `[{"bver":1.5}]`, `[{"vb":1}]`, `[{"vd":1}]`, `[{"bu":true}]`} {
+		var p Pack
+		if _, ok := p.UnmarshalJSON([]byte(tc)).(*json.UnmarshalTypeError); !ok {
+			t.Errorf("JSON decoding of %s should return a *json.UnmarshalTypeError", tc)
+		}
+	}
+	var p Pack
+	if err := p.UnmarshalJSON([]byte(`[{"vd":"!"}]`)); err != ErrInvalidDataValue {
+		t.Errorf("JSON decoding of an invalid data value should return ErrInvalidDataValue, not %v", err)
+	}
+}
+
+func benchmarkPack() Pack {
+	p := make(Pack, 0, 300)
+	for i := 0; i < 100; i++ {
+		t := 1.5e+09 + float64(i)*60
+		p = append(p,
+			Record{Name: "urn:dev:ow:10e2073a01080063:temp", Unit: Celsius, Time: t, Value: Float(20 + float64(i%10)/2)},
+			Record{Name: "urn:dev:ow:10e2073a01080063:energy", Unit: Joule, Time: t, Sum: Float(float64(i) * 100)},
+			Record{Name: "urn:dev:ow:10e2073a01080063:open", Time: t, BoolValue: Bool(i%2 == 0)},
+		)
+	}
+	return p
+}
+
+func BenchmarkMarshalJSON(b *testing.B) {
+	p := benchmarkPack()
+	var buf []byte
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf, _ = p.AppendJSON(buf[:0])
+	}
+}
+
+func BenchmarkMarshalJSONReflect(b *testing.B) {
+	p := benchmarkPack()
+	r := make([]reflectRecord, len(p))
+	for i := range p {
+		r[i] = reflectRecord(p[i])
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		json.Marshal(r)
+	}
+}
+
+func BenchmarkUnmarshalJSON(b *testing.B) {
+	js, _ := json.Marshal(benchmarkPack())
+	var p Pack
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		p.UnmarshalJSON(js)
+	}
+}
+
+func BenchmarkUnmarshalJSONReflect(b *testing.B) {
+	js, _ := json.Marshal(benchmarkPack())
+	var r []reflectRecord
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		json.Unmarshal(js, &r)
+	}
+}
@@ -0,0 +1,121 @@
+// Package lwm2m maps SenML records to LwM2M object/instance/resource paths,
+// as defined by the SenML content formats of OMA LwM2M 1.1 (section 7.4.5 of the Transport Bindings).
+package lwm2m
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/objenious/senml"
+)
+
+// ErrInvalidPath is returned when a name is not a valid LwM2M path.
+var ErrInvalidPath = errors.New("lwm2m: invalid path")
+
+// maxID is the maximum value of a LwM2M ID, 65535 being reserved.
+const maxID = 65534
+
+// Path is a LwM2M path, e.g. /3303/0/5700.
+type Path struct {
+	ObjectID           uint16
+	ObjectInstanceID   uint16
+	ResourceID         uint16
+	ResourceInstanceID uint16
+	// Depth is the number of IDs in the path : 1 for an object, 2 for an object instance,
+	// 3 for a resource and 4 for a resource instance.
+	Depth int
+}
+
+// NewPath builds a path from 1 to 4 IDs.
+func NewPath(ids ...uint16) (Path, error) {
+	if len(ids) < 1 || len(ids) > 4 {
+		return Path{}, ErrInvalidPath
+	}
+	p := Path{Depth: len(ids)}
+	for i, id := range ids {
+		if id > maxID {
+			return Path{}, ErrInvalidPath
+		}
+		*p.id(i) = id
+	}
+	return p, nil
+}
+
+// ParsePath parses a path, such as /3303/0/5700.
+func ParsePath(s string) (Path, error) {
+	if !strings.HasPrefix(s, "/") {
+		return Path{}, ErrInvalidPath
+	}
+	parts := strings.Split(s[1:], "/")
+	if len(parts) > 4 {
+		return Path{}, ErrInvalidPath
+	}
+	ids := make([]uint16, len(parts))
+	for i, part := range parts {
+		// reject signs and leading zeros, which would make the string form ambiguous
+		if part == "" || part[0] < '0' || part[0] > '9' || (len(part) > 1 && part[0] == '0') {
+			return Path{}, ErrInvalidPath
+		}
+		id, err := strconv.ParseUint(part, 10, 16)
+		if err != nil {
+			return Path{}, ErrInvalidPath
+		}
+		ids[i] = uint16(id)
+	}
+	return NewPath(ids...)
+}
+
+// RecordPath returns the path of a resolved record (i.e. from a normalized pack).
+func RecordPath(r senml.Record) (Path, error) {
+	return ParsePath(r.Name)
+}
+
+// IDs returns the IDs of the path.
+func (p Path) IDs() []uint16 {
+	ids := make([]uint16, 0, p.Depth)
+	for i := 0; i < p.Depth && i < 4; i++ {
+		ids = append(ids, *p.id(i))
+	}
+	return ids
+}
+
+// String implements fmt.Stringer.
+func (p Path) String() string {
+	b := make([]byte, 0, 24)
+	for _, id := range p.IDs() {
+		b = append(b, '/')
+		b = strconv.AppendUint(b, uint64(id), 10)
+	}
+	if len(b) == 0 {
+		return "/"
+	}
+	return string(b)
+}
+
+// Contains checks if p2 is p, or is below p in the object tree.
+// The root path (with a zero depth) contains all paths.
+func (p Path) Contains(p2 Path) bool {
+	if p2.Depth < p.Depth {
+		return false
+	}
+	for i := 0; i < p.Depth; i++ {
+		if *p.id(i) != *p2.id(i) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Path) id(i int) *uint16 {
+	switch i {
+	case 0:
+		return &p.ObjectID
+	case 1:
+		return &p.ObjectInstanceID
+	case 2:
+		return &p.ResourceID
+	default:
+		return &p.ResourceInstanceID
+	}
+}
@@ -0,0 +1,65 @@
+package lwm2m
+
+import (
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	tcs := []struct {
+		s    string
+		path Path
+		err  error
+	}{
+		{s: "/3303", path: Path{ObjectID: 3303, Depth: 1}},
+		{s: "/3303/0", path: Path{ObjectID: 3303, Depth: 2}},
+		{s: "/3303/0/5700", path: Path{ObjectID: 3303, ResourceID: 5700, Depth: 3}},
+		{s: "/3/0/7/1", path: Path{ObjectID: 3, ResourceID: 7, ResourceInstanceID: 1, Depth: 4}},
+		{s: "/65534/65534", path: Path{ObjectID: 65534, ObjectInstanceID: 65534, Depth: 2}},
+		{s: "", err: ErrInvalidPath},
+		{s: "/", err: ErrInvalidPath},
+		{s: "3303/0", err: ErrInvalidPath},
+		{s: "/3303/", err: ErrInvalidPath},
+		{s: "/3303//5700", err: ErrInvalidPath},
+		{s: "/3/0/7/1/2", err: ErrInvalidPath},
+		{s: "/65535", err: ErrInvalidPath},
+		{s: "/70000", err: ErrInvalidPath},
+		{s: "/+3", err: ErrInvalidPath},
+		{s: "/03", err: ErrInvalidPath},
+		{s: "/temp", err: ErrInvalidPath},
+	}
+	for _, tc := range tcs {
+		path, err := ParsePath(tc.s)
+		if err != tc.err {
+			t.Errorf("ParsePath(%q) should return error %v, not %v", tc.s, tc.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if path != tc.path {
+			t.Errorf("ParsePath(%q) should return %+v not %+v", tc.s, tc.path, path)
+		}
+		if path.String() != tc.s {
+			t.Errorf("String of %+v should be %s not %s", path, tc.s, path.String())
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	obj, _ := NewPath(3303)
+	inst, _ := NewPath(3303, 0)
+	res, _ := NewPath(3303, 0, 5700)
+	other, _ := NewPath(3303, 1, 5700)
+	if !obj.Contains(res) || !inst.Contains(res) || !res.Contains(res) {
+		t.Errorf("%s should be contained in its parents", res)
+	}
+	if inst.Contains(other) || res.Contains(inst) {
+		t.Errorf("Contains should return false for unrelated paths")
+	}
+	if !(Path{}).Contains(res) {
+		t.Errorf("the root path should contain all paths")
+	}
+	if _, err := NewPath(); err != ErrInvalidPath {
+		t.Errorf("NewPath without ids should return ErrInvalidPath")
+	}
+}
@@ -0,0 +1,162 @@
+package lwm2m
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/objenious/senml"
+)
+
+var (
+	// ErrUnsupportedType is returned when encoding a resource value with an unsupported Go type.
+	ErrUnsupportedType = errors.New("lwm2m: unsupported value type")
+	// ErrInvalidObjectLink is returned when decoding a malformed object link.
+	ErrInvalidObjectLink = errors.New("lwm2m: invalid object link")
+)
+
+// ObjectLink is a LwM2M Objlnk value, referencing an object instance.
+type ObjectLink struct {
+	ObjectID         uint16
+	ObjectInstanceID uint16
+}
+
+// String implements fmt.Stringer, using the "ObjectID:InstanceID" notation.
+func (l ObjectLink) String() string {
+	return fmt.Sprintf("%d:%d", l.ObjectID, l.ObjectInstanceID)
+}
+
+// Resource is a LwM2M resource (or resource instance) value.
+type Resource struct {
+	Path Path
+	// Value is the value of the resource. When encoding, it can be one of
+	// int, int64, uint, uint64 (Integer and Unsigned Integer), float32, float64 (Float), bool (Boolean),
+	// string (String), []byte (Opaque), time.Time (Time) or ObjectLink (Objlnk).
+	// When decoding, it is one of float64, bool, string or []byte, depending on the SenML value field.
+	Value interface{}
+	// Time is the SenML time of the value, if any.
+	Time float64
+}
+
+// Encode builds a SenML pack from LwM2M resources.
+// If base is not the root path (i.e. has a non zero depth), it is used as the base name of the pack,
+// and the names of the records are relative to it. All resources must then be strictly below base.
+//
+// Record has no field for the LwM2M "vlo" label, so object links are encoded as Opaque values,
+// using the same 4 bytes representation as the TLV content format.
+func Encode(base Path, resources []Resource) (senml.Pack, error) {
+	p := make(senml.Pack, 0, len(resources))
+	prefix := ""
+	if base.Depth > 0 {
+		prefix = base.String() + "/"
+	}
+	for i, res := range resources {
+		if res.Path.Depth <= base.Depth || !base.Contains(res.Path) {
+			return nil, ErrInvalidPath
+		}
+		r := senml.Record{
+			Name: strings.TrimPrefix(res.Path.String(), prefix),
+			Time: res.Time,
+		}
+		if err := setValue(&r, res.Value); err != nil {
+			return nil, err
+		}
+		if i == 0 && prefix != "" {
+			r.BaseName = prefix
+		}
+		p = append(p, r)
+	}
+	return p, nil
+}
+
+func setValue(r *senml.Record, v interface{}) error {
+	switch v := v.(type) {
+	case int:
+		r.Value = senml.Float(float64(v))
+	case int64:
+		r.Value = senml.Float(float64(v))
+	case uint:
+		r.Value = senml.Float(float64(v))
+	case uint64:
+		r.Value = senml.Float(float64(v))
+	case float32:
+		r.Value = senml.Float(float64(v))
+	case float64:
+		r.Value = senml.Float(v)
+	case bool:
+		r.BoolValue = senml.Bool(v)
+	case string:
+		r.StringValue = v
+	case []byte:
+		r.DataValue = v
+	case time.Time:
+		r.Value = senml.Float(float64(v.Unix()))
+	case ObjectLink:
+		r.DataValue = make([]byte, 4)
+		binary.BigEndian.PutUint16(r.DataValue, v.ObjectID)
+		binary.BigEndian.PutUint16(r.DataValue[2:], v.ObjectInstanceID)
+	default:
+		return ErrUnsupportedType
+	}
+	return nil
+}
+
+// Decode extracts LwM2M resources from a SenML pack.
+// The pack is normalized first, so that names are resolved.
+func Decode(p senml.Pack) ([]Resource, error) {
+	p = p.Normalize()
+	resources := make([]Resource, 0, len(p))
+	for _, r := range p {
+		path, err := RecordPath(r)
+		if err != nil {
+			return nil, err
+		}
+		res := Resource{Path: path, Time: r.Time}
+		switch {
+		case r.Value != nil:
+			res.Value = *r.Value
+		case r.BoolValue != nil:
+			res.Value = *r.BoolValue
+		case r.StringValue != "":
+			res.Value = r.StringValue
+		case r.DataValue != nil:
+			res.Value = r.DataValue
+		case r.Sum != nil:
+			res.Value = *r.Sum
+		}
+		resources = append(resources, res)
+	}
+	return resources, nil
+}
+
+// Integer returns the value of an Integer (or Unsigned Integer) resource.
+func (r Resource) Integer() (int64, bool) {
+	f, ok := r.Value.(float64)
+	if !ok || f != float64(int64(f)) {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// GoTime returns the value of a Time resource.
+func (r Resource) GoTime() (time.Time, bool) {
+	f, ok := r.Value.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return senml.GoTime(f), true
+}
+
+// ObjectLink returns the value of an Objlnk resource.
+func (r Resource) ObjectLink() (ObjectLink, error) {
+	b, ok := r.Value.([]byte)
+	if !ok || len(b) != 4 {
+		return ObjectLink{}, ErrInvalidObjectLink
+	}
+	return ObjectLink{
+		ObjectID:         binary.BigEndian.Uint16(b),
+		ObjectInstanceID: binary.BigEndian.Uint16(b[2:]),
+	}, nil
+}
@@ -0,0 +1,95 @@
+package lwm2m
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/objenious/senml"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	base, _ := NewPath(3, 0)
+	ts := time.Date(2018, 7, 11, 0, 0, 0, 0, time.UTC)
+	path := func(ids ...uint16) Path {
+		p, _ := NewPath(ids...)
+		return p
+	}
+	resources := []Resource{
+		{Path: path(3, 0, 0), Value: "Open Mobile Alliance"},
+		{Path: path(3, 0, 9), Value: 95},
+		{Path: path(3, 0, 13), Value: ts},
+		{Path: path(3, 0, 7, 0), Value: uint64(3800)},
+		{Path: path(3, 0, 7, 1), Value: 5000},
+		{Path: path(3, 0, 22), Value: []byte{0xca, 0xfe}},
+		{Path: path(3, 0, 23), Value: ObjectLink{ObjectID: 3303, ObjectInstanceID: 1}},
+		{Path: path(3, 0, 24), Value: true},
+		{Path: path(3, 0, 25), Value: 23.5},
+	}
+	p, err := Encode(base, resources)
+	if err != nil {
+		t.Fatalf("Encode returned an error : %s", err)
+	}
+	enc, _ := json.Marshal(p)
+	exp := `[{"bn":"/3/0/","n":"0","vs":"Open Mobile Alliance"},{"n":"9","v":95},{"n":"13","v":1531267200},` +
+		`{"n":"7/0","v":3800},{"n":"7/1","v":5000},{"n":"22","vd":"yv4"},{"n":"23","vd":"DOcAAQ"},{"n":"24","vb":true},{"n":"25","v":23.5}]`
+	if string(enc) != exp {
+		t.Errorf("Encode should return %s not %s", exp, enc)
+	}
+
+	dec, err := Decode(p)
+	if err != nil {
+		t.Fatalf("Decode returned an error : %s", err)
+	}
+	if len(dec) != len(resources) {
+		t.Fatalf("Decode should return %d resources not %d", len(resources), len(dec))
+	}
+	for i := range dec {
+		if dec[i].Path != resources[i].Path {
+			t.Errorf("resource %d should have path %s not %s", i, resources[i].Path, dec[i].Path)
+		}
+	}
+	if dec[0].Value != "Open Mobile Alliance" {
+		t.Errorf("String value should be decoded, not %v", dec[0].Value)
+	}
+	if i, ok := dec[1].Integer(); !ok || i != 95 {
+		t.Errorf("Integer value should be 95, not %v", dec[1].Value)
+	}
+	if ti, ok := dec[2].GoTime(); !ok || !ti.Equal(ts) {
+		t.Errorf("Time value should be %s, not %v", ts, dec[2].Value)
+	}
+	if b, ok := dec[5].Value.([]byte); !ok || !bytes.Equal(b, []byte{0xca, 0xfe}) {
+		t.Errorf("Opaque value should be decoded, not %v", dec[5].Value)
+	}
+	if l, err := dec[6].ObjectLink(); err != nil || l.String() != "3303:1" {
+		t.Errorf("Objlnk value should be 3303:1, not %v", dec[6].Value)
+	}
+	if _, err := dec[0].ObjectLink(); err != ErrInvalidObjectLink {
+		t.Errorf("a String value is not an Objlnk")
+	}
+	if _, ok := dec[8].Integer(); ok {
+		t.Errorf("a Float value is not an Integer")
+	}
+	if dec[7].Value != true {
+		t.Errorf("Boolean value should be decoded, not %v", dec[7].Value)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	base, _ := NewPath(3, 0)
+	inst, _ := NewPath(3, 1)
+	res, _ := NewPath(3, 0, 0)
+	if _, err := Encode(base, []Resource{{Path: base, Value: 1}}); err != ErrInvalidPath {
+		t.Errorf("encoding a resource at the base path should return ErrInvalidPath, not %v", err)
+	}
+	if _, err := Encode(base, []Resource{{Path: inst, Value: 1}}); err != ErrInvalidPath {
+		t.Errorf("encoding a resource outside of the base path should return ErrInvalidPath, not %v", err)
+	}
+	if _, err := Encode(base, []Resource{{Path: res, Value: struct{}{}}}); err != ErrUnsupportedType {
+		t.Errorf("encoding an unsupported type should return ErrUnsupportedType, not %v", err)
+	}
+	if _, err := Decode(senml.Pack{{Name: "temp", Value: senml.Float(1)}}); err != ErrInvalidPath {
+		t.Errorf("decoding a non LwM2M name should return ErrInvalidPath, not %v", err)
+	}
+}
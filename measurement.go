@@ -0,0 +1,92 @@
+//go:build go1.18
+// +build go1.18
+
+package senml
+
+import "time"
+
+// Value is the set of Go types of SenML values : numeric (v), boolean (vb), string (vs) and data (vd) values.
+type Value interface {
+	float64 | bool | string | []byte
+}
+
+// Measurement is a typed SenML value, with its resolved name, unit and time.
+// A zero Time means that the measurement has no time.
+type Measurement[T Value] struct {
+	Name  string
+	Unit  Unit
+	Time  time.Time
+	Value T
+}
+
+// NewMeasurement returns a measurement.
+func NewMeasurement[T Value](name string, unit Unit, v T, t time.Time) Measurement[T] {
+	return Measurement[T]{Name: name, Unit: unit, Time: t, Value: v}
+}
+
+// Record converts the measurement to a record.
+func (m Measurement[T]) Record() Record {
+	r := Record{Name: m.Name, Unit: m.Unit}
+	if !m.Time.IsZero() {
+		r.Time = Time(m.Time)
+	}
+	switch v := interface{}(m.Value).(type) {
+	case float64:
+		r.Value = Float(v)
+	case bool:
+		r.BoolValue = Bool(v)
+	case string:
+		r.StringValue = v
+	case []byte:
+		if v == nil {
+			v = []byte{}
+		}
+		r.DataValue = v
+	}
+	return r
+}
+
+// FromRecord extracts a measurement of type T from a normalized record.
+// It returns false if the record does not hold a value of type T.
+func FromRecord[T Value](r Record) (Measurement[T], bool) {
+	m := Measurement[T]{Name: r.Name, Unit: r.Unit}
+	if r.Time != 0 {
+		m.Time = r.GoTime()
+	}
+	var v interface{}
+	switch interface{}(m.Value).(type) {
+	case float64:
+		if r.Value == nil {
+			return m, false
+		}
+		v = *r.Value
+	case bool:
+		if r.BoolValue == nil {
+			return m, false
+		}
+		v = *r.BoolValue
+	case string:
+		if r.StringValue == "" {
+			return m, false
+		}
+		v = r.StringValue
+	case []byte:
+		if r.DataValue == nil {
+			return m, false
+		}
+		v = r.DataValue
+	}
+	m.Value = v.(T)
+	return m, true
+}
+
+// Measurements extracts the measurements of type T from a pack. The pack is normalized first.
+func Measurements[T Value](p Pack) []Measurement[T] {
+	var res []Measurement[T]
+	for _, r := range p.Normalize() {
+		if m, ok := FromRecord[T](r); ok {
+			res = append(res, m)
+		}
+	}
+	return res
+}
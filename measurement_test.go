@@ -0,0 +1,63 @@
+//go:build go1.18
+// +build go1.18
+
+package senml
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMeasurement(t *testing.T) {
+	now := time.Unix(1320067464, 0)
+	tcs := []struct {
+		m interface{ Record() Record }
+		r Record
+	}{
+		{m: NewMeasurement("temp", Celsius, 23.1, now), r: Record{Name: "temp", Unit: Celsius, Time: 1.320067464e+09, Value: Float(23.1)}},
+		{m: NewMeasurement("open", "", true, time.Time{}), r: Record{Name: "open", BoolValue: True}},
+		{m: NewMeasurement("label", "", "kitchen", now), r: Record{Name: "label", Time: 1.320067464e+09, StringValue: "kitchen"}},
+		{m: NewMeasurement("raw", "", []byte{0xca, 0xfe}, now), r: Record{Name: "raw", Time: 1.320067464e+09, DataValue: []byte{0xca, 0xfe}}},
+		{m: NewMeasurement[[]byte]("empty", "", nil, now), r: Record{Name: "empty", Time: 1.320067464e+09, DataValue: []byte{}}},
+	}
+	for _, tc := range tcs {
+		if r := tc.m.Record(); !reflect.DeepEqual(r, tc.r) {
+			t.Errorf("record of %+v should be %+v not %+v", tc.m, tc.r, r)
+		}
+	}
+
+	r := Record{Name: "temp", Unit: Celsius, Time: 1.320067464e+09, Value: Float(23.1)}
+	if m, ok := FromRecord[float64](r); !ok || !reflect.DeepEqual(m, NewMeasurement("temp", Celsius, 23.1, now)) {
+		t.Errorf("extraction of a float from %+v returned %+v, %v", r, m, ok)
+	}
+	if _, ok := FromRecord[bool](r); ok {
+		t.Errorf("extraction of a bool from %+v should fail", r)
+	}
+	if _, ok := FromRecord[string](r); ok {
+		t.Errorf("extraction of a string from %+v should fail", r)
+	}
+	if _, ok := FromRecord[[]byte](r); ok {
+		t.Errorf("extraction of data from %+v should fail", r)
+	}
+	if m, ok := FromRecord[bool](Record{Name: "open", BoolValue: False}); !ok || m.Value || !m.Time.IsZero() {
+		t.Errorf("extraction of a bool returned %+v, %v", m, ok)
+	}
+
+	p := Pack{
+		{BaseName: "urn:dev:ow:10e2073a01080063:", BaseTime: 1.320067464e+09, BaseUnit: Celsius, Name: "temp", Value: Float(23.1)},
+		{Name: "open", BoolValue: True},
+		{Name: "temp", Time: 60, Value: Float(23.4)},
+	}
+	temps := Measurements[float64](p)
+	exp := []Measurement[float64]{
+		NewMeasurement("urn:dev:ow:10e2073a01080063:temp", Celsius, 23.1, now),
+		NewMeasurement("urn:dev:ow:10e2073a01080063:temp", Celsius, 23.4, now.Add(time.Minute)),
+	}
+	if !reflect.DeepEqual(temps, exp) {
+		t.Errorf("float measurements of %+v should be %+v not %+v", p, exp, temps)
+	}
+	if opens := Measurements[bool](p); len(opens) != 1 || !opens[0].Value {
+		t.Errorf("bool measurements of %+v should be true not %+v", p, opens)
+	}
+}
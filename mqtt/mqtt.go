@@ -0,0 +1,196 @@
+// Package mqtt maps SenML packs to MQTT messages, with topics derived from record names.
+package mqtt
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/objenious/senml"
+	"github.com/objenious/senml/internal/split"
+)
+
+// NamePlaceholder is the placeholder replaced by the name in topic templates.
+const NamePlaceholder = "{name}"
+
+var (
+	// ErrInvalidTemplate is returned when a topic template does not contain exactly one NamePlaceholder.
+	ErrInvalidTemplate = errors.New("mqtt: invalid topic template")
+	// ErrInvalidTopic is returned when a name can not be used in a topic (e.g. it contains wildcards),
+	// or when a topic does not match the template.
+	ErrInvalidTopic = errors.New("mqtt: invalid topic")
+)
+
+// Split defines how packs are split into messages.
+type Split int
+
+const (
+	// SplitByName publishes a message per resolved name. The name is carried by the topic,
+	// and by the base name of the payload, as records without name are not valid.
+	SplitByName Split = iota
+	// SplitByBaseName publishes a message per base name. The base name is carried by the topic,
+	// and records keep their names relative to it.
+	SplitByBaseName
+)
+
+// Message is a MQTT message.
+type Message struct {
+	Topic   string
+	Payload []byte
+}
+
+// Publisher is implemented by MQTT clients.
+type Publisher interface {
+	Publish(topic string, payload []byte) error
+}
+
+// Mapper maps packs to MQTT messages, and back.
+type Mapper struct {
+	// Format is the format of the payloads. Defaults to JSON.
+	Format senml.Format
+	// Template is the topic template, e.g. "sensors/{name}/senml". Defaults to "{name}".
+	Template string
+	// Split defines how packs are split into messages.
+	Split Split
+}
+
+func (m Mapper) format() senml.Format {
+	if m.Format == senml.UnknownFormat {
+		return senml.JSON
+	}
+	return m.Format
+}
+
+func (m Mapper) template() (prefix, suffix string, err error) {
+	tpl := m.Template
+	if tpl == "" {
+		tpl = NamePlaceholder
+	}
+	if strings.Count(tpl, NamePlaceholder) != 1 {
+		return "", "", ErrInvalidTemplate
+	}
+	i := strings.Index(tpl, NamePlaceholder)
+	return tpl[:i], tpl[i+len(NamePlaceholder):], nil
+}
+
+// Topic returns the topic for a name.
+func (m Mapper) Topic(name string) (string, error) {
+	prefix, suffix, err := m.template()
+	if err != nil {
+		return "", err
+	}
+	if strings.ContainsAny(name, "+#\x00") {
+		return "", ErrInvalidTopic
+	}
+	return prefix + name + suffix, nil
+}
+
+// Name extracts the name from a topic.
+func (m Mapper) Name(topic string) (string, error) {
+	prefix, suffix, err := m.template()
+	if err != nil {
+		return "", err
+	}
+	if len(topic) < len(prefix)+len(suffix) || !strings.HasPrefix(topic, prefix) || !strings.HasSuffix(topic, suffix) {
+		return "", ErrInvalidTopic
+	}
+	return topic[len(prefix) : len(topic)-len(suffix)], nil
+}
+
+// Messages splits a pack into messages.
+func (m Mapper) Messages(p senml.Pack) ([]Message, error) {
+	var names []string
+	var groups map[string]senml.Pack
+	if m.Split == SplitByBaseName {
+		names, groups = splitByBaseName(p)
+	} else {
+		names, groups = splitByName(p)
+	}
+	msgs := make([]Message, 0, len(names))
+	for _, name := range names {
+		if len(groups[name]) == 0 {
+			continue
+		}
+		topic, err := m.Topic(name)
+		if err != nil {
+			return nil, err
+		}
+		payload, err := senml.Marshal(m.format(), groups[name])
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, Message{Topic: topic, Payload: payload})
+	}
+	return msgs, nil
+}
+
+// Publish splits a pack into messages, and publishes them.
+func (m Mapper) Publish(pub Publisher, p senml.Pack) error {
+	msgs, err := m.Messages(p)
+	if err != nil {
+		return err
+	}
+	for _, msg := range msgs {
+		if err := pub.Publish(msg.Topic, msg.Payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pack rebuilds a normalized pack from messages. The name extracted from the topic of each message
+// is prepended to the resolved names of its records, unless its payload has a base name, i.e. the names are complete.
+func (m Mapper) Pack(msgs ...Message) (senml.Pack, error) {
+	var res senml.Pack
+	for _, msg := range msgs {
+		name, err := m.Name(msg.Topic)
+		if err != nil {
+			return nil, err
+		}
+		p, err := senml.Unmarshal(m.format(), msg.Payload)
+		if err != nil {
+			return nil, err
+		}
+		prefix := name
+		for _, r := range p {
+			if r.BaseName != "" {
+				prefix = ""
+				break
+			}
+		}
+		for _, r := range p.Normalize() {
+			r.Name = prefix + r.Name
+			res = append(res, r)
+		}
+	}
+	return res.Normalize(), nil
+}
+
+func splitByName(p senml.Pack) ([]string, map[string]senml.Pack) {
+	var names []string
+	groups := map[string]senml.Pack{}
+	for _, r := range p.Normalize() {
+		name := r.Name
+		if _, found := groups[name]; !found {
+			names = append(names, name)
+			r.BaseName = name
+		}
+		r.Name = ""
+		groups[name] = append(groups[name], r)
+	}
+	return names, groups
+}
+
+// splitByBaseName groups records by base name, and resolves each group with names relative to its base name.
+func splitByBaseName(p senml.Pack) ([]string, map[string]senml.Pack) {
+	var names []string
+	groups := map[string]senml.Pack{}
+	for _, g := range split.ByBaseName(p) {
+		name := g[0].BaseName
+		names = append(names, name)
+		for i := range g {
+			g[i].BaseName = ""
+		}
+		groups[name] = g.Normalize()
+	}
+	return names, groups
+}
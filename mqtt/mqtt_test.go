@@ -0,0 +1,194 @@
+package mqtt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/objenious/senml"
+)
+
+// broker is an in-memory stand-in for a MQTT broker.
+type broker struct {
+	subscriptions map[string][]chan Message
+	fail          bool
+}
+
+func newBroker() *broker {
+	return &broker{subscriptions: map[string][]chan Message{}}
+}
+
+func (b *broker) Subscribe(filter string) <-chan Message {
+	ch := make(chan Message, 100)
+	b.subscriptions[filter] = append(b.subscriptions[filter], ch)
+	return ch
+}
+
+func (b *broker) Publish(topic string, payload []byte) error {
+	if b.fail {
+		return errors.New("broker unavailable")
+	}
+	for filter, chs := range b.subscriptions {
+		if match(filter, topic) {
+			for _, ch := range chs {
+				ch <- Message{Topic: topic, Payload: payload}
+			}
+		}
+	}
+	return nil
+}
+
+func match(filter, topic string) bool {
+	f, t := strings.Split(filter, "/"), strings.Split(topic, "/")
+	for i := range f {
+		switch {
+		case f[i] == "#":
+			return true
+		case i >= len(t):
+			return false
+		case f[i] != "+" && f[i] != t[i]:
+			return false
+		}
+	}
+	return len(f) == len(t)
+}
+
+func receive(ch <-chan Message) []Message {
+	var msgs []Message
+	for {
+		select {
+		case m := <-ch:
+			msgs = append(msgs, m)
+		default:
+			return msgs
+		}
+	}
+}
+
+var pack = senml.Pack{
+	{BaseName: "urn:dev:ow:10e2073a01080063:", BaseTime: 1.320067464e+09, BaseUnit: senml.Celsius, Name: "temp", Value: senml.Float(23.1)},
+	{Name: "hum", Unit: senml.RelativeHumidity, Value: senml.Float(40)},
+	{Name: "temp", Time: 60, Value: senml.Float(23.4)},
+	{BaseName: "urn:dev:ow:10e2073a01080064:", Name: "temp", Time: 60, Value: senml.Float(21.2)},
+}
+
+func TestSplitByName(t *testing.T) {
+	b := newBroker()
+	sub := b.Subscribe("sensors/+/senml")
+	m := Mapper{Template: "sensors/{name}/senml"}
+	if err := m.Publish(b, pack); err != nil {
+		t.Fatalf("Publish returned an error : %s", err)
+	}
+	msgs := receive(sub)
+	exp := []Message{
+		{Topic: "sensors/urn:dev:ow:10e2073a01080063:temp/senml", Payload: []byte(`[{"bn":"urn:dev:ow:10e2073a01080063:temp","u":"Cel","t":1320067464,"v":23.1},{"u":"Cel","t":1320067524,"v":23.4}]`)},
+		{Topic: "sensors/urn:dev:ow:10e2073a01080063:hum/senml", Payload: []byte(`[{"bn":"urn:dev:ow:10e2073a01080063:hum","u":"%RH","t":1320067464,"v":40}]`)},
+		{Topic: "sensors/urn:dev:ow:10e2073a01080064:temp/senml", Payload: []byte(`[{"bn":"urn:dev:ow:10e2073a01080064:temp","u":"Cel","t":1320067524,"v":21.2}]`)},
+	}
+	checkMessages(t, exp, msgs)
+	checkNames(t, msgs)
+
+	p, err := m.Pack(msgs...)
+	if err != nil {
+		t.Fatalf("Pack returned an error : %s", err)
+	}
+	if norm := pack.Normalize(); !norm.Equals(p) {
+		t.Errorf("Pack should return %+v not %+v", norm, p)
+	}
+}
+
+func TestSplitByBaseName(t *testing.T) {
+	b := newBroker()
+	sub := b.Subscribe("#")
+	m := Mapper{Split: SplitByBaseName, Format: senml.XML}
+	if err := m.Publish(b, pack); err != nil {
+		t.Fatalf("Publish returned an error : %s", err)
+	}
+	msgs := receive(sub)
+	exp := []Message{
+		{Topic: "urn:dev:ow:10e2073a01080063:", Payload: []byte(`<sensml xmlns="urn:ietf:params:xml:ns:senml">` +
+			`<senml n="temp" u="Cel" t="1.320067464e+09" v="23.1"></senml>` +
+			`<senml n="hum" u="%RH" t="1.320067464e+09" v="40"></senml>` +
+			`<senml n="temp" u="Cel" t="1.320067524e+09" v="23.4"></senml></sensml>`)},
+		{Topic: "urn:dev:ow:10e2073a01080064:", Payload: []byte(`<sensml xmlns="urn:ietf:params:xml:ns:senml">` +
+			`<senml n="temp" u="Cel" t="1.320067524e+09" v="21.2"></senml></sensml>`)},
+	}
+	checkMessages(t, exp, msgs)
+
+	p, err := m.Pack(msgs...)
+	if err != nil {
+		t.Fatalf("Pack returned an error : %s", err)
+	}
+	if norm := pack.Normalize(); !norm.Equals(p) {
+		t.Errorf("Pack should return %+v not %+v", norm, p)
+	}
+}
+
+// checkNames checks that each payload can be resolved on its own to valid names.
+func checkNames(t *testing.T, msgs []Message) {
+	for _, msg := range msgs {
+		p, err := senml.Unmarshal(senml.JSON, msg.Payload)
+		if err != nil {
+			t.Fatalf("decoding of %s returned an error : %s", msg.Payload, err)
+		}
+		for _, r := range p.Normalize() {
+			if !senml.ValidName(r.Name) {
+				t.Errorf("payload %s should only contain valid names, not %q", msg.Payload, r.Name)
+			}
+		}
+	}
+}
+
+func checkMessages(t *testing.T, exp, msgs []Message) {
+	if len(msgs) != len(exp) {
+		t.Fatalf("%d messages should be published, not %d : %+v", len(exp), len(msgs), msgs)
+	}
+	for i := range exp {
+		if msgs[i].Topic != exp[i].Topic || string(msgs[i].Payload) != string(exp[i].Payload) {
+			t.Errorf("message %d should be %s %s not %s %s", i, exp[i].Topic, exp[i].Payload, msgs[i].Topic, msgs[i].Payload)
+		}
+	}
+}
+
+func TestTopics(t *testing.T) {
+	m := Mapper{Template: "a/{name}/b"}
+	if topic, err := m.Topic("foo"); err != nil || topic != "a/foo/b" {
+		t.Errorf("Topic should return a/foo/b, not %s (%v)", topic, err)
+	}
+	if name, err := m.Name("a/foo/b"); err != nil || name != "foo" {
+		t.Errorf("Name should return foo, not %s (%v)", name, err)
+	}
+	for _, topic := range []string{"a/b", "b/foo/b", "a/foo/c"} {
+		if _, err := m.Name(topic); err != ErrInvalidTopic {
+			t.Errorf("Name(%s) should return ErrInvalidTopic, not %v", topic, err)
+		}
+	}
+	for _, name := range []string{"foo+", "foo#"} {
+		if _, err := m.Topic(name); err != ErrInvalidTopic {
+			t.Errorf("Topic(%s) should return ErrInvalidTopic, not %v", name, err)
+		}
+	}
+	for _, tpl := range []string{"sensors", "{name}/{name}"} {
+		m := Mapper{Template: tpl}
+		if _, err := m.Topic("foo"); err != ErrInvalidTemplate {
+			t.Errorf("Topic with template %s should return ErrInvalidTemplate, not %v", tpl, err)
+		}
+		if _, err := m.Name("foo"); err != ErrInvalidTemplate {
+			t.Errorf("Name with template %s should return ErrInvalidTemplate, not %v", tpl, err)
+		}
+	}
+}
+
+func TestPublishErrors(t *testing.T) {
+	b := newBroker()
+	b.fail = true
+	if err := (Mapper{}).Publish(b, pack); err == nil {
+		t.Errorf("Publish should return the error of the publisher")
+	}
+	if err := (Mapper{Format: senml.CBOR}).Publish(newBroker(), pack); err != senml.ErrUnsupportedFormat {
+		t.Errorf("Publish should return ErrUnsupportedFormat, not %v", err)
+	}
+	if _, err := (Mapper{}).Pack(Message{Topic: "foo", Payload: []byte("{")}); err == nil {
+		t.Errorf("Pack should return decoding errors")
+	}
+}
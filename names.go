@@ -0,0 +1,105 @@
+package senml
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// URN prefixes of device names, as defined in https://tools.ietf.org/html/rfc9039.
+const (
+	URNPrefixMAC     = "urn:dev:mac:"
+	URNPrefixOneWire = "urn:dev:ow:"
+	URNPrefixOPS     = "urn:dev:ops:"
+)
+
+func isAlphaNum(c byte) bool {
+	return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9')
+}
+
+func isNameChar(c byte) bool {
+	return isAlphaNum(c) || c == '-' || c == ':' || c == '.' || c == '/' || c == '_'
+}
+
+// ValidName checks if a resolved name is valid, as defined in https://tools.ietf.org/html/rfc8428#section-4.5.1 :
+// it only contains the A-Z, a-z, 0-9, "-", ":", ".", "/" and "_" characters, and starts with a letter or a digit.
+func ValidName(name string) bool {
+	if name == "" || !isAlphaNum(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isNameChar(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// SanitizeName maps an arbitrary identifier to a valid name : invalid characters are replaced by "_",
+// and "x" is prepended if the identifier does not start with a letter or a digit.
+// An empty identifier returns an empty string. Different identifiers may return the same name.
+func SanitizeName(id string) string {
+	if ValidName(id) || id == "" {
+		return id
+	}
+	var sb bytes.Buffer
+	for i, c := range id {
+		if i == 0 && (c >= 0x80 || !isAlphaNum(byte(c))) {
+			sb.WriteByte('x')
+		}
+		if c < 0x80 && isNameChar(byte(c)) {
+			sb.WriteRune(c)
+		} else {
+			sb.WriteByte('_')
+		}
+	}
+	return sb.String()
+}
+
+// URNMAC returns the name of a device identified by its MAC address (EUI-48 or EUI-64),
+// e.g. "urn:dev:mac:0024befffe804ff1".
+func URNMAC(mac net.HardwareAddr) string {
+	b := make([]byte, 0, len(URNPrefixMAC)+2*len(mac))
+	b = append(b, URNPrefixMAC...)
+	for _, c := range mac {
+		b = append(b, hex[c>>4], hex[c&0xf])
+	}
+	return string(b)
+}
+
+// URNOneWire returns the name of a 1-Wire device, with the family code as the most significant byte
+// of its id, e.g. "urn:dev:ow:10e2073a01080063".
+func URNOneWire(id uint64) string {
+	s := strconv.FormatUint(id, 16)
+	return URNPrefixOneWire + strings.Repeat("0", 16-len(s)) + s
+}
+
+// URNOPS returns the name of a device identified by the OUI of its manufacturer, its product class (optional)
+// and its serial number, e.g. "urn:dev:ops:0024be-sensor-42".
+//
+// As "-" separates the components, and "%" is not valid in names, the characters of the product class
+// and serial number other than letters, digits and "." are escaped as "_" followed by the 2 hexadecimal digits of each byte.
+func URNOPS(oui uint32, productClass, serial string) string {
+	s := strconv.FormatUint(uint64(oui&0xffffff), 16)
+	s = URNPrefixOPS + strings.Repeat("0", 6-len(s)) + s + "-"
+	if productClass != "" {
+		s += escapeURNComponent(productClass) + "-"
+	}
+	return s + escapeURNComponent(serial)
+}
+
+func escapeURNComponent(s string) string {
+	var sb bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isAlphaNum(c) || c == '.' {
+			sb.WriteByte(c)
+			continue
+		}
+		sb.WriteByte('_')
+		sb.WriteByte(hex[c>>4])
+		sb.WriteByte(hex[c&0xf])
+	}
+	return sb.String()
+}
@@ -0,0 +1,74 @@
+package senml
+
+import (
+	"net"
+	"testing"
+)
+
+func TestValidName(t *testing.T) {
+	tcs := []struct {
+		name  string
+		valid bool
+	}{
+		{"urn:dev:ow:10e2073a01080063:temp", true},
+		{"3303/0/5700", true},
+		{"a.b-c_d", true},
+		{"", false},
+		{"_temp", false},
+		{"/3303/0/5700", false},
+		{"temp C", false},
+		{"température", false},
+		{"temp%20", false},
+	}
+	for _, tc := range tcs {
+		if valid := ValidName(tc.name); valid != tc.valid {
+			t.Errorf("ValidName(%q) should return %v not %v", tc.name, tc.valid, valid)
+		}
+	}
+}
+
+func TestSanitizeName(t *testing.T) {
+	tcs := []struct {
+		id   string
+		name string
+	}{
+		{"urn:dev:ow:10e2073a01080063", "urn:dev:ow:10e2073a01080063"},
+		{"Living Room/Température", "Living_Room/Temp_rature"},
+		{"_sensor", "x_sensor"},
+		{"éa", "x_a"},
+		{"", ""},
+	}
+	for _, tc := range tcs {
+		name := SanitizeName(tc.id)
+		if name != tc.name {
+			t.Errorf("SanitizeName(%q) should return %q not %q", tc.id, tc.name, name)
+		}
+		if tc.id != "" && !ValidName(name) {
+			t.Errorf("SanitizeName(%q) should return a valid name, not %q", tc.id, name)
+		}
+	}
+}
+
+func TestURN(t *testing.T) {
+	mac, _ := net.ParseMAC("00:24:be:ff:fe:80:4f:f1")
+	tcs := []struct {
+		urn string
+		exp string
+	}{
+		{URNMAC(mac), "urn:dev:mac:0024befffe804ff1"},
+		{URNMAC(net.HardwareAddr{0x00, 0x24, 0xBE, 0x01, 0x02, 0x03}), "urn:dev:mac:0024be010203"},
+		{URNOneWire(0x10e2073a01080063), "urn:dev:ow:10e2073a01080063"},
+		{URNOneWire(0x63), "urn:dev:ow:0000000000000063"},
+		{URNOPS(0x0024be, "sensor", "42"), "urn:dev:ops:0024be-sensor-42"},
+		{URNOPS(0xbe, "", "SN.42"), "urn:dev:ops:0000be-SN.42"},
+		{URNOPS(0x0024be, "temp-sensor", "a/b c_d"), "urn:dev:ops:0024be-temp_2dsensor-a_2fb_20c_5fd"},
+	}
+	for _, tc := range tcs {
+		if tc.urn != tc.exp {
+			t.Errorf("URN should be %s not %s", tc.exp, tc.urn)
+		}
+		if !ValidName(tc.urn + ":temp") {
+			t.Errorf("URN %s should be a valid base name", tc.urn)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package senml
+
+import (
+	"math"
+	"strconv"
+)
+
+// NonFinitePolicy defines how non-finite numbers (NaN, +Inf and -Inf) are handled,
+// as they can not be represented in JSON, but may be reported by faulty sensors.
+//
+// NullNonFinite and StringNonFinite only apply to values (v, s, bv and bs) :
+// records with non-finite times (bt, t and ut) are always rejected, unless they are dropped.
+type NonFinitePolicy int
+
+const (
+	// RejectNonFinite returns a *NonFiniteError for records with non-finite numbers. It is the default policy.
+	RejectNonFinite NonFinitePolicy = iota
+	// DropNonFinite silently drops the records with non-finite numbers.
+	DropNonFinite
+	// NullNonFinite encodes non-finite values as null in JSON, and omits them in XML, i.e. the values are lost.
+	NullNonFinite
+	// StringNonFinite encodes non-finite values as the "NaN", "Infinity" and "-Infinity" strings in JSON,
+	// and as the "NaN", "INF" and "-INF" xs:double values in XML. Decoding accepts them.
+	StringNonFinite
+
+	// keepNonFinite keeps non-finite numbers, as Normalize does.
+	keepNonFinite NonFinitePolicy = -1
+)
+
+// NonFiniteError is returned when a record holds a non-finite number, and the policy is RejectNonFinite.
+type NonFiniteError struct {
+	// Record is the index of the record in its pack, or -1 if the record is not part of a pack.
+	Record int
+	// Label is the SenML label of the field, e.g. "v".
+	Label string
+	Value float64
+}
+
+func (e *NonFiniteError) Error() string {
+	s := "senml: "
+	if e.Record >= 0 {
+		s += "record " + strconv.Itoa(e.Record) + ": "
+	}
+	return s + "non-finite " + e.Label + " " + strconv.FormatFloat(e.Value, 'g', -1, 64)
+}
+
+func isFinite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+// nonFinite returns the label and value of the first non-finite number of the record, if any.
+// If times is true, only times are checked.
+func (r *Record) nonFinite(times bool) (string, float64, bool) {
+	if !isFinite(r.BaseTime) {
+		return "bt", r.BaseTime, true
+	}
+	if !isFinite(r.Time) {
+		return "t", r.Time, true
+	}
+	if !isFinite(r.UpdateTime) {
+		return "ut", r.UpdateTime, true
+	}
+	if times {
+		return "", 0, false
+	}
+	if r.BaseValue != nil && !isFinite(*r.BaseValue) {
+		return "bv", *r.BaseValue, true
+	}
+	if r.BaseSum != nil && !isFinite(*r.BaseSum) {
+		return "bs", *r.BaseSum, true
+	}
+	if r.Value != nil && !isFinite(*r.Value) {
+		return "v", *r.Value, true
+	}
+	if r.Sum != nil && !isFinite(*r.Sum) {
+		return "s", *r.Sum, true
+	}
+	return "", 0, false
+}
+
+// checkFinite applies the policy to the i-th record : it returns whether the record should be kept, or an error.
+func (r *Record) checkFinite(i int, policy NonFinitePolicy) (bool, error) {
+	if policy == keepNonFinite {
+		return true, nil
+	}
+	label, f, found := r.nonFinite(policy == NullNonFinite || policy == StringNonFinite)
+	if !found {
+		return true, nil
+	}
+	if policy == DropNonFinite {
+		return false, nil
+	}
+	return false, &NonFiniteError{Record: i, Label: label, Value: f}
+}
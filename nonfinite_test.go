@@ -0,0 +1,121 @@
+package senml
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"math"
+	"reflect"
+	"testing"
+)
+
+var nonFiniteTestPack = Pack{
+	{BaseName: "dev:", Name: "temp", Value: Float(21)},
+	{Name: "temp", Time: 60, Value: Float(math.NaN())},
+	{Name: "energy", Time: 60, Sum: Float(math.Inf(1))},
+	{Name: "min", Time: 60, Value: Float(math.Inf(-1))},
+}
+
+func TestNonFiniteJSON(t *testing.T) {
+	tcs := []struct {
+		policy NonFinitePolicy
+		json   string
+		err    error
+	}{
+		{
+			policy: RejectNonFinite,
+			err:    &NonFiniteError{Record: 1, Label: "v", Value: math.NaN()},
+		},
+		{
+			policy: DropNonFinite,
+			json:   `[{"bn":"dev:","n":"temp","v":21}]`,
+		},
+		{
+			policy: NullNonFinite,
+			json:   `[{"bn":"dev:","n":"temp","v":21},{"n":"temp","t":60,"v":null},{"n":"energy","t":60,"s":null},{"n":"min","t":60,"v":null}]`,
+		},
+		{
+			policy: StringNonFinite,
+			json:   `[{"bn":"dev:","n":"temp","v":21},{"n":"temp","t":60,"v":"NaN"},{"n":"energy","t":60,"s":"Infinity"},{"n":"min","t":60,"v":"-Infinity"}]`,
+		},
+	}
+	for _, tc := range tcs {
+		enc, err := EncodeJSON(nonFiniteTestPack, JSONOptions{NonFinite: tc.policy})
+		if tc.err != nil {
+			if err == nil || err.Error() != tc.err.Error() {
+				t.Errorf("JSON encoding with policy %d should return %v not %v", tc.policy, tc.err, err)
+			}
+			continue
+		}
+		if err != nil || string(enc) != tc.json {
+			t.Errorf("JSON encoding with policy %d should be %s not %s (%v)", tc.policy, tc.json, enc, err)
+		}
+	}
+
+	if _, err := json.Marshal(nonFiniteTestPack); err == nil {
+		t.Error("json.Marshal of non-finite values should return an error")
+	}
+	if _, err := EncodeJSON(Pack{{Name: "temp", Time: math.NaN(), Value: Float(1)}}, JSONOptions{NonFinite: StringNonFinite}); err == nil {
+		t.Error("JSON encoding of a non-finite time should return an error")
+	}
+
+	src := `[{"n":"a","v":"NaN","bv":"Infinity","s":"-Infinity"}]`
+	p, err := DecodeJSON([]byte(src), JSONOptions{NonFinite: StringNonFinite})
+	if err != nil || len(p) != 1 || !math.IsNaN(*p[0].Value) || !math.IsInf(*p[0].BaseValue, 1) || !math.IsInf(*p[0].Sum, -1) {
+		t.Errorf("JSON decoding of %s should return non-finite values not %+v (%v)", src, p, err)
+	}
+	for _, src := range []string{`[{"n":"a","v":"nan"}]`, `[{"n":"a","t":"NaN"}]`} {
+		if _, err := DecodeJSON([]byte(src), JSONOptions{NonFinite: StringNonFinite}); err == nil {
+			t.Errorf("JSON decoding of %s should return an error", src)
+		}
+	}
+	if _, err := DecodeJSON([]byte(`[{"n":"a","v":"NaN"}]`), JSONOptions{}); err == nil {
+		t.Error("JSON decoding of a string value should return an error by default")
+	}
+}
+
+func TestNonFiniteXML(t *testing.T) {
+	if _, err := xml.Marshal(nonFiniteTestPack); err == nil {
+		t.Error("xml.Marshal of non-finite values should return an error")
+	}
+	enc, err := EncodeXML(nonFiniteTestPack, XMLOptions{NonFinite: StringNonFinite})
+	exp := `<sensml xmlns="urn:ietf:params:xml:ns:senml"><senml bn="dev:" n="temp" v="21"></senml><senml n="temp" t="60" v="NaN"></senml><senml n="energy" t="60" s="INF"></senml><senml n="min" t="60" v="-INF"></senml></sensml>`
+	if err != nil || string(enc) != exp {
+		t.Errorf("XML encoding should be %s not %s (%v)", exp, enc, err)
+	}
+
+	p, err := DecodeXML(enc, XMLOptions{NonFinite: StringNonFinite})
+	if err != nil || !p.Equals(nonFiniteTestPack) {
+		t.Errorf("XML decoding of %s should be %+v not %+v (%v)", enc, nonFiniteTestPack, p, err)
+	}
+	_, err = DecodeXML(enc, XMLOptions{})
+	if nf, ok := err.(*NonFiniteError); !ok || nf.Record != 1 || nf.Label != "v" {
+		t.Errorf("XML decoding of %s should return a *NonFiniteError for record 1 not %v", enc, err)
+	}
+	p, err = DecodeXML(enc, XMLOptions{NonFinite: DropNonFinite})
+	if err != nil || !p.Equals(nonFiniteTestPack[:1]) {
+		t.Errorf("XML decoding of %s should drop non-finite values not return %+v (%v)", enc, p, err)
+	}
+	p, err = DecodeXML(enc, XMLOptions{NonFinite: NullNonFinite})
+	if exp := (Pack{nonFiniteTestPack[0], {Name: "temp", Time: 60}, {Name: "energy", Time: 60}, {Name: "min", Time: 60}}); err != nil || !p.Equals(exp) {
+		t.Errorf("XML decoding of %s should be %+v not %+v (%v)", enc, exp, p, err)
+	}
+}
+
+func TestNormalizeWithNonFinite(t *testing.T) {
+	src := Pack{
+		{BaseName: "dev:", BaseValue: Float(math.Inf(1)), Name: "temp", Value: Float(1)},
+		{Name: "open", BoolValue: True},
+	}
+	_, err := src.NormalizeWith(NormalizeOptions{})
+	if !reflect.DeepEqual(err, &NonFiniteError{Record: 0, Label: "v", Value: math.Inf(1)}) {
+		t.Errorf("normalization of %+v should return a *NonFiniteError not %v", src, err)
+	}
+	n, err := src.NormalizeWith(NormalizeOptions{NonFinite: DropNonFinite})
+	if exp := (Pack{{Name: "dev:open", BoolValue: True}}); err != nil || !n.Equals(exp) {
+		t.Errorf("normalization of %+v should be %+v not %+v (%v)", src, exp, n, err)
+	}
+	n, err = src.NormalizeWith(NormalizeOptions{NonFinite: StringNonFinite})
+	if exp := src.Normalize(); err != nil || !n.Equals(exp) {
+		t.Errorf("normalization of %+v should be %+v not %+v (%v)", src, exp, n, err)
+	}
+}
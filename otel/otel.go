@@ -0,0 +1,245 @@
+// Package otel converts SenML packs to and from OpenTelemetry metric data points.
+//
+// The types of this package mirror the OpenTelemetry metric data model (go.opentelemetry.io/otel/sdk/metric/metricdata),
+// so that they can be copied to an OTLP exporter without this package depending on the OpenTelemetry SDK.
+//
+// Records with a Value are converted to gauge data points, and records with a Sum to cumulative sum data points.
+// Other records are ignored. The metric name is the name of the record relative to its base name,
+// and the base name and unit are stored as attributes.
+package otel
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/objenious/senml"
+	"github.com/objenious/senml/internal/split"
+)
+
+// Attribute keys set on data points.
+const (
+	BaseNameKey = "senml.base_name"
+	UnitKey     = "senml.unit"
+)
+
+// Temporality is the temporality of a sum.
+type Temporality int
+
+// Temporalities of sums, as defined by the OpenTelemetry data model.
+const (
+	UndefinedTemporality Temporality = iota
+	CumulativeTemporality
+	DeltaTemporality
+)
+
+// Attribute is a data point attribute.
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// DataPoint is a metric data point.
+type DataPoint struct {
+	Attributes []Attribute
+	StartTime  time.Time
+	Time       time.Time
+	Value      float64
+}
+
+// Attribute returns the value of the attribute with key k.
+func (dp DataPoint) Attribute(k string) (string, bool) {
+	for _, a := range dp.Attributes {
+		if a.Key == k {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// Aggregation is the data of a metric, either a Gauge or a Sum, like metricdata.Aggregation.
+type Aggregation interface {
+	privateAggregation()
+}
+
+// Gauge is a metric of instantaneous values.
+type Gauge struct {
+	DataPoints []DataPoint
+}
+
+func (Gauge) privateAggregation() {}
+
+// Sum is a metric of aggregated values.
+type Sum struct {
+	DataPoints  []DataPoint
+	Temporality Temporality
+	IsMonotonic bool
+}
+
+func (Sum) privateAggregation() {}
+
+// Metric is a named metric, with either Gauge or Sum data.
+type Metric struct {
+	Name string
+	Unit string
+	Data Aggregation
+}
+
+// Exporter is implemented by metric exporters.
+type Exporter interface {
+	Export(ctx context.Context, metrics []Metric) error
+}
+
+// Converter converts SenML packs to and from OpenTelemetry metrics.
+type Converter struct {
+	// Separator, if set, is used to split base names into components, stored in the attributes named by BaseNameKeys.
+	// e.g. with ":" as separator, and "urn", "scheme", "type", "id" as keys, the "urn:dev:ow:10e2073a01080063:" base name
+	// is converted to urn=urn, scheme=dev, type=ow, id=10e2073a01080063 attributes.
+	Separator    string
+	BaseNameKeys []string
+}
+
+// Metrics converts a pack to metrics. Relative times are resolved against now.
+// Metrics are returned in order of first appearance. The start time of sum data points is the earliest time
+// of their series, i.e. of the data points with the same attributes.
+func (c Converter) Metrics(p senml.Pack, now time.Time) []Metric {
+	var res []Metric
+	idx := map[string]int{}
+	for _, g := range split.ByBaseName(p) {
+		bn := g[0].BaseName
+		for _, r := range g.NormalizeAt(now) {
+			var value float64
+			sum := false
+			switch {
+			case r.Value != nil:
+				value = *r.Value
+			case r.Sum != nil:
+				value = *r.Sum
+				sum = true
+			default:
+				continue
+			}
+			name := strings.TrimPrefix(r.Name, bn)
+			key := name
+			if sum {
+				key += "\xffsum"
+			}
+			i, found := idx[key]
+			if !found {
+				i = len(res)
+				idx[key] = i
+				m := Metric{Name: name, Unit: string(r.Unit)}
+				if sum {
+					m.Data = Sum{Temporality: CumulativeTemporality}
+				} else {
+					m.Data = Gauge{}
+				}
+				res = append(res, m)
+			}
+			dp := DataPoint{
+				Attributes: c.attributes(bn, r.Unit),
+				Time:       r.GoTime().UTC(),
+				Value:      value,
+			}
+			switch data := res[i].Data.(type) {
+			case Sum:
+				data.DataPoints = append(data.DataPoints, dp)
+				res[i].Data = data
+			case Gauge:
+				data.DataPoints = append(data.DataPoints, dp)
+				res[i].Data = data
+			}
+		}
+	}
+	for _, m := range res {
+		if data, ok := m.Data.(Sum); ok {
+			setStartTimes(data.DataPoints)
+		}
+	}
+	return res
+}
+
+// setStartTimes sets the start time of cumulative data points, required by OTLP, to the earliest time of their series.
+func setStartTimes(dps []DataPoint) {
+	start := map[string]time.Time{}
+	keys := make([]string, len(dps))
+	for i, dp := range dps {
+		for _, a := range dp.Attributes {
+			keys[i] += a.Key + "\xff" + a.Value + "\xff"
+		}
+		if t, found := start[keys[i]]; !found || dp.Time.Before(t) {
+			start[keys[i]] = dp.Time
+		}
+	}
+	for i := range dps {
+		dps[i].StartTime = start[keys[i]]
+	}
+}
+
+func (c Converter) attributes(bn string, unit senml.Unit) []Attribute {
+	var attrs []Attribute
+	if bn != "" {
+		attrs = append(attrs, Attribute{Key: BaseNameKey, Value: bn})
+		if c.Separator != "" {
+			for i, part := range strings.Split(strings.TrimSuffix(bn, c.Separator), c.Separator) {
+				if i < len(c.BaseNameKeys) && c.BaseNameKeys[i] != "" {
+					attrs = append(attrs, Attribute{Key: c.BaseNameKeys[i], Value: part})
+				}
+			}
+		}
+	}
+	if unit != "" {
+		attrs = append(attrs, Attribute{Key: UnitKey, Value: string(unit)})
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Key < attrs[j].Key })
+	return attrs
+}
+
+// Pack converts metrics to a pack of resolved records.
+// The record names are built from the base name attribute and the metric name.
+// If a data point has no unit attribute, the unit of the metric is used.
+func (c Converter) Pack(metrics []Metric) senml.Pack {
+	p := senml.Pack{}
+	for _, m := range metrics {
+		var dps []DataPoint
+		sum := false
+		switch data := m.Data.(type) {
+		case Gauge:
+			dps = data.DataPoints
+		case Sum:
+			dps = data.DataPoints
+			sum = true
+		}
+		for _, dp := range dps {
+			bn, _ := dp.Attribute(BaseNameKey)
+			unit, ok := dp.Attribute(UnitKey)
+			if !ok {
+				unit = m.Unit
+			}
+			r := senml.Record{
+				Name: bn + m.Name,
+				Unit: senml.Unit(unit),
+			}
+			if !dp.Time.IsZero() {
+				r.Time = senml.Time(dp.Time)
+			}
+			if sum {
+				r.Sum = senml.Float(dp.Value)
+			} else {
+				r.Value = senml.Float(dp.Value)
+			}
+			p = append(p, r)
+		}
+	}
+	return p
+}
+
+// Export converts a pack to metrics, and exports them.
+func (c Converter) Export(ctx context.Context, e Exporter, p senml.Pack, now time.Time) error {
+	metrics := c.Metrics(p, now)
+	if len(metrics) == 0 {
+		return nil
+	}
+	return e.Export(ctx, metrics)
+}
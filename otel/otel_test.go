@@ -0,0 +1,97 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/objenious/senml"
+)
+
+// exporter is an in-memory metric exporter.
+type exporter struct {
+	metrics []Metric
+	err     error
+}
+
+func (e *exporter) Export(ctx context.Context, metrics []Metric) error {
+	if e.err != nil {
+		return e.err
+	}
+	e.metrics = append(e.metrics, metrics...)
+	return nil
+}
+
+func TestMetrics(t *testing.T) {
+	now := time.Date(2018, 7, 11, 0, 0, 0, 0, time.UTC)
+	p := senml.Pack{
+		{BaseName: "urn:dev:ow:10e2073a01080063:", BaseTime: 1.5e+09, BaseUnit: senml.Celsius, Name: "temp", Value: senml.Float(23.1)},
+		{Name: "energy", Unit: senml.Joule, Sum: senml.Float(1200)},
+		{Name: "energy", Unit: senml.Joule, Time: 60, Sum: senml.Float(1300)},
+		{Name: "label", StringValue: "kitchen"},
+		{BaseName: "urn:dev:ow:10e2073a01080064:", BaseTime: -60, Name: "temp", Value: senml.Float(21.2)},
+	}
+	conv := Converter{Separator: ":", BaseNameKeys: []string{"", "", "type", "id"}}
+	e := &exporter{}
+	if err := conv.Export(context.Background(), e, p, now); err != nil {
+		t.Fatalf("Export returned an error : %s", err)
+	}
+	attrs := func(bn, id, unit string) []Attribute {
+		return []Attribute{{Key: "id", Value: id}, {Key: BaseNameKey, Value: bn}, {Key: UnitKey, Value: unit}, {Key: "type", Value: "ow"}}
+	}
+	exp := []Metric{
+		{
+			Name: "temp",
+			Unit: "Cel",
+			Data: Gauge{DataPoints: []DataPoint{
+				{Attributes: attrs("urn:dev:ow:10e2073a01080063:", "10e2073a01080063", "Cel"), Time: time.Unix(1.5e+09, 0).UTC(), Value: 23.1},
+				{Attributes: attrs("urn:dev:ow:10e2073a01080064:", "10e2073a01080064", "Cel"), Time: now.Add(-time.Minute), Value: 21.2},
+			}},
+		},
+		{
+			Name: "energy",
+			Unit: "J",
+			Data: Sum{Temporality: CumulativeTemporality, DataPoints: []DataPoint{
+				{Attributes: attrs("urn:dev:ow:10e2073a01080063:", "10e2073a01080063", "J"), StartTime: time.Unix(1.5e+09, 0).UTC(), Time: time.Unix(1.5e+09, 0).UTC(), Value: 1200},
+				{Attributes: attrs("urn:dev:ow:10e2073a01080063:", "10e2073a01080063", "J"), StartTime: time.Unix(1.5e+09, 0).UTC(), Time: time.Unix(1.5e+09+60, 0).UTC(), Value: 1300},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(e.metrics, exp) {
+		t.Errorf("exported metrics should be %+v not %+v", exp, e.metrics)
+	}
+
+	dec := conv.Pack(e.metrics)
+	norm := senml.Pack{
+		{Name: "urn:dev:ow:10e2073a01080063:temp", Unit: senml.Celsius, Time: 1.5e+09, Value: senml.Float(23.1)},
+		{Name: "urn:dev:ow:10e2073a01080064:temp", Unit: senml.Celsius, Time: senml.Time(now) - 60, Value: senml.Float(21.2)},
+		{Name: "urn:dev:ow:10e2073a01080063:energy", Unit: senml.Joule, Time: 1.5e+09, Sum: senml.Float(1200)},
+		{Name: "urn:dev:ow:10e2073a01080063:energy", Unit: senml.Joule, Time: 1.5e+09 + 60, Sum: senml.Float(1300)},
+	}
+	if !norm.Equals(dec) {
+		t.Errorf("Pack should return %+v not %+v", norm, dec)
+	}
+}
+
+func TestPack(t *testing.T) {
+	metrics := []Metric{
+		{Name: "temp", Unit: "Cel", Data: Gauge{DataPoints: []DataPoint{{Value: 20}}}},
+		{Name: "ignored", Data: nil},
+	}
+	exp := senml.Pack{{Name: "temp", Unit: senml.Celsius, Value: senml.Float(20)}}
+	if p := (Converter{}).Pack(metrics); !exp.Equals(p) {
+		t.Errorf("Pack should return %+v not %+v", exp, p)
+	}
+}
+
+func TestExportErrors(t *testing.T) {
+	e := &exporter{err: errors.New("unavailable")}
+	if err := (Converter{}).Export(context.Background(), e, senml.Pack{{Name: "foo", Value: senml.Float(1)}}, time.Now()); err != e.err {
+		t.Errorf("Export should return the error of the exporter, not %v", err)
+	}
+	if err := (Converter{}).Export(context.Background(), e, senml.Pack{{Name: "foo", StringValue: "bar"}}, time.Now()); err != nil {
+		t.Errorf("Export should not call the exporter without metrics, got %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+// Package prometheus converts SenML packs to and from the Prometheus text exposition format
+// (https://prometheus.io/docs/instrumenting/exposition_formats/) and remote write samples.
+//
+// Records with a Value are exposed as gauges, and records with a Sum as counters. Other records are ignored.
+// Metric names are derived from resolved names, with invalid characters replaced by underscores,
+// and the unit added as a suffix.
+package prometheus
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/objenious/senml"
+)
+
+// MetricNameLabel is the label holding the metric name in remote write time series.
+const MetricNameLabel = "__name__"
+
+// units maps SenML units to Prometheus unit suffixes.
+var units = map[senml.Unit]string{
+	senml.Meter:              "meters",
+	senml.Kilogram:           "kilograms",
+	senml.Second:             "seconds",
+	senml.Ampere:             "amperes",
+	senml.Kelvin:             "kelvin",
+	senml.Celsius:            "celsius",
+	senml.Hertz:              "hertz",
+	senml.Pascal:             "pascals",
+	senml.Joule:              "joules",
+	senml.Watt:               "watts",
+	senml.Volt:               "volts",
+	senml.Ohm:                "ohms",
+	senml.Lux:                "lux",
+	senml.Liter:              "liters",
+	senml.CubicMeter:         "cubic_meters",
+	senml.MeterPerSecond:     "meters_per_second",
+	senml.Bit:                "bits",
+	senml.BitPerSecond:       "bits_per_second",
+	senml.DegreesLatitude:    "degrees_latitude",
+	senml.DegreesLongitude:   "degrees_longitude",
+	senml.Decibel:            "decibels",
+	senml.Percentage:         "percent",
+	senml.RelativeHumidity:   "relative_humidity_percent",
+	senml.EnergyLevel:        "battery_percent",
+	senml.EnergyRemaining:    "battery_seconds",
+	senml.Switch:             "ratio",
+	senml.Count:              "count",
+	senml.EventRate:          "per_second",
+	senml.BeatsPerMinute:     "beats_per_minute",
+	senml.WattPerSquareMeter: "watts_per_square_meter",
+}
+
+// Converter converts SenML packs to and from Prometheus metrics.
+type Converter struct {
+	// NameLabel, if set, is the label in which the resolved name of records is stored,
+	// so that it can be restored when importing metrics.
+	NameLabel string
+}
+
+// MetricName returns the Prometheus metric name for a record name and unit.
+// Counters get a "_total" suffix.
+func MetricName(name string, unit senml.Unit, counter bool) string {
+	n := sanitize(name)
+	if suffix := unitSuffix(unit); suffix != "" && !strings.HasSuffix(n, "_"+suffix) {
+		n += "_" + suffix
+	}
+	if counter {
+		n += "_total"
+	}
+	return n
+}
+
+// parseMetricName is the reverse of MetricName. The unit is only restored for units with a known suffix.
+func parseMetricName(n string, counter bool) (string, senml.Unit) {
+	if counter {
+		n = strings.TrimSuffix(n, "_total")
+	}
+	var unit senml.Unit
+	var suffix string
+	for u, s := range units {
+		// the longest suffix wins, e.g. meters_per_second vs per_second
+		if len(s) > len(suffix) && strings.HasSuffix(n, "_"+s) {
+			unit, suffix = u, s
+		}
+	}
+	if suffix != "" {
+		n = n[:len(n)-len(suffix)-1]
+	}
+	return n, unit
+}
+
+func unitSuffix(unit senml.Unit) string {
+	if unit == "" {
+		return ""
+	}
+	if s, ok := units[unit]; ok {
+		return s
+	}
+	s := strings.ToLower(string(unit))
+	s = strings.Replace(s, "/", "_per_", -1)
+	s = strings.Replace(s, "%", "percent", -1)
+	return strings.Trim(sanitize(s), "_")
+}
+
+// sanitize replaces all characters that are not allowed in metric names by underscores.
+// Colons are also replaced, as they are reserved for recording rules.
+func sanitize(name string) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+			buf.WriteByte(c)
+		case c >= '0' && c <= '9':
+			if i == 0 {
+				buf.WriteByte('_')
+			}
+			buf.WriteByte(c)
+		default:
+			buf.WriteByte('_')
+		}
+	}
+	if buf.Len() == 0 {
+		return "_"
+	}
+	return buf.String()
+}
+
+// metric is a single sample converted from a record.
+type metric struct {
+	name      string
+	counter   bool
+	senmlName string
+	value     float64
+	timestamp int64
+}
+
+func (c Converter) metrics(p senml.Pack) []metric {
+	ms := make([]metric, 0, len(p))
+	for _, r := range p {
+		m := metric{senmlName: r.Name}
+		switch {
+		case r.Value != nil:
+			m.value = *r.Value
+		case r.Sum != nil:
+			m.value = *r.Sum
+			m.counter = true
+		default:
+			continue
+		}
+		m.name = MetricName(r.Name, r.Unit, m.counter)
+		if r.Time != 0 {
+			m.timestamp = r.GoTime().UnixNano() / 1e6
+		}
+		ms = append(ms, m)
+	}
+	return ms
+}
+
+func (c Converter) record(name string, counter bool, labels map[string]string, value float64, timestamp int64) senml.Record {
+	n, unit := parseMetricName(name, counter)
+	if c.NameLabel != "" && labels[c.NameLabel] != "" {
+		n = labels[c.NameLabel]
+	}
+	r := senml.Record{Name: n, Unit: unit}
+	if counter {
+		r.Sum = senml.Float(value)
+	} else {
+		r.Value = senml.Float(value)
+	}
+	if timestamp != 0 {
+		r.Time = float64(timestamp) / 1e3
+	}
+	return r
+}
@@ -0,0 +1,214 @@
+package prometheus
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/objenious/senml"
+)
+
+func TestMetricName(t *testing.T) {
+	tcs := []struct {
+		name    string
+		unit    senml.Unit
+		counter bool
+		metric  string
+	}{
+		{name: "urn:dev:ow:10e2073a01080063:temp", unit: senml.Celsius, metric: "urn_dev_ow_10e2073a01080063_temp_celsius"},
+		{name: "temp_celsius", unit: senml.Celsius, metric: "temp_celsius"},
+		{name: "energy", unit: senml.Joule, counter: true, metric: "energy_joules_total"},
+		{name: "speed", unit: senml.MeterPerSecond, metric: "speed_meters_per_second"},
+		{name: "flow", unit: senml.CubicMeterPerSecond, metric: "flow_m3_per_s"},
+		{name: "1wire.temp", metric: "_1wire_temp"},
+		{name: "", metric: "_"},
+	}
+	for _, tc := range tcs {
+		if m := MetricName(tc.name, tc.unit, tc.counter); m != tc.metric {
+			t.Errorf("MetricName(%s, %s) should be %s not %s", tc.name, tc.unit, tc.metric, m)
+		}
+	}
+	if n, u := parseMetricName("speed_meters_per_second", false); n != "speed" || u != senml.MeterPerSecond {
+		t.Errorf("parseMetricName should return the longest unit suffix, not %s %s", n, u)
+	}
+}
+
+var pack = senml.Pack{
+	{Name: "urn:dev:ow:10e2073a01080063:temp", Unit: senml.Celsius, Time: 1.320067464e+09, Value: senml.Float(23.1)},
+	{Name: "urn:dev:ow:10e2073a01080063:energy", Unit: senml.Joule, Time: 1.320067464e+09, Sum: senml.Float(1200)},
+	{Name: "urn:dev:ow:10e2073a01080063:label", StringValue: "kitchen"},
+	{Name: "urn:dev:ow:10e2073a01080063:temp", Unit: senml.Celsius, Time: 1.320067524e+09, Value: senml.Float(23.4)},
+	{Name: "urn:dev:ow:10e2073a01080064:temp", Unit: senml.Celsius, Time: 1.320067524e+09, Value: senml.Float(21.2)},
+}
+
+func TestText(t *testing.T) {
+	tcs := []struct {
+		conv Converter
+		text string
+		dec  senml.Pack
+	}{
+		{
+			text: `# TYPE urn_dev_ow_10e2073a01080063_temp_celsius gauge
+urn_dev_ow_10e2073a01080063_temp_celsius 23.4 1320067524000
+# TYPE urn_dev_ow_10e2073a01080063_energy_joules_total counter
+urn_dev_ow_10e2073a01080063_energy_joules_total 1200 1320067464000
+# TYPE urn_dev_ow_10e2073a01080064_temp_celsius gauge
+urn_dev_ow_10e2073a01080064_temp_celsius 21.2 1320067524000
+`,
+			dec: senml.Pack{
+				{Name: "urn_dev_ow_10e2073a01080063_temp", Unit: senml.Celsius, Time: 1.320067524e+09, Value: senml.Float(23.4)},
+				{Name: "urn_dev_ow_10e2073a01080063_energy", Unit: senml.Joule, Time: 1.320067464e+09, Sum: senml.Float(1200)},
+				{Name: "urn_dev_ow_10e2073a01080064_temp", Unit: senml.Celsius, Time: 1.320067524e+09, Value: senml.Float(21.2)},
+			},
+		},
+		{
+			conv: Converter{NameLabel: "senml_name"},
+			text: `# TYPE urn_dev_ow_10e2073a01080063_temp_celsius gauge
+urn_dev_ow_10e2073a01080063_temp_celsius{senml_name="urn:dev:ow:10e2073a01080063:temp"} 23.4 1320067524000
+# TYPE urn_dev_ow_10e2073a01080063_energy_joules_total counter
+urn_dev_ow_10e2073a01080063_energy_joules_total{senml_name="urn:dev:ow:10e2073a01080063:energy"} 1200 1320067464000
+# TYPE urn_dev_ow_10e2073a01080064_temp_celsius gauge
+urn_dev_ow_10e2073a01080064_temp_celsius{senml_name="urn:dev:ow:10e2073a01080064:temp"} 21.2 1320067524000
+`,
+			dec: senml.Pack{
+				{Name: "urn:dev:ow:10e2073a01080063:temp", Unit: senml.Celsius, Time: 1.320067524e+09, Value: senml.Float(23.4)},
+				{Name: "urn:dev:ow:10e2073a01080063:energy", Unit: senml.Joule, Time: 1.320067464e+09, Sum: senml.Float(1200)},
+				{Name: "urn:dev:ow:10e2073a01080064:temp", Unit: senml.Celsius, Time: 1.320067524e+09, Value: senml.Float(21.2)},
+			},
+		},
+	}
+	for _, tc := range tcs {
+		var buf bytes.Buffer
+		if err := tc.conv.WriteText(&buf, pack); err != nil {
+			t.Errorf("WriteText returned an error : %s", err)
+		}
+		if buf.String() != tc.text {
+			t.Errorf("WriteText should write\n%s\nnot\n%s", tc.text, buf.String())
+		}
+		dec, err := tc.conv.ParseText(strings.NewReader(tc.text))
+		if err != nil {
+			t.Errorf("ParseText returned an error : %s", err)
+		}
+		if !tc.dec.Equals(dec) {
+			t.Errorf("ParseText should return %+v not %+v", tc.dec, dec)
+		}
+	}
+}
+
+func TestTextCollision(t *testing.T) {
+	p := senml.Pack{
+		{Name: "temp:1", Unit: senml.Celsius, Time: 1.320067464e+09, Value: senml.Float(23.1)},
+		{Name: "temp/1", Unit: senml.Celsius, Time: 1.320067464e+09, Value: senml.Float(21.2)},
+	}
+	var buf bytes.Buffer
+	if err := (Converter{}).WriteText(&buf, p); err == nil {
+		t.Errorf("WriteText of names converted to the same metric name should return an error")
+	}
+	buf.Reset()
+	if err := (Converter{NameLabel: "senml_name"}).WriteText(&buf, p); err != nil {
+		t.Errorf("WriteText with a name label returned an error : %s", err)
+	}
+	exp := `# TYPE temp_1_celsius gauge
+temp_1_celsius{senml_name="temp:1"} 23.1 1320067464000
+temp_1_celsius{senml_name="temp/1"} 21.2 1320067464000
+`
+	if buf.String() != exp {
+		t.Errorf("WriteText should write\n%s\nnot\n%s", exp, buf.String())
+	}
+}
+
+func TestParseText(t *testing.T) {
+	text := `# HELP requests_total Total requests.
+requests_total{method="post",code="200"} 1027
+temperature{path="a \"b\"\\c\n"} -Inf
+battery_percent{ } 98 1500000000000
+`
+	exp := senml.Pack{
+		{Name: "requests", Sum: senml.Float(1027)},
+		{Name: "temperature", Value: senml.Float(-1)},
+		{Name: "battery", Unit: senml.Percentage, Time: 1.5e+09, Value: senml.Float(98)},
+	}
+	dec, err := Converter{NameLabel: "path"}.ParseText(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("ParseText returned an error : %s", err)
+	}
+	if len(dec) != 3 || dec[1].Name != "a \"b\"\\c\n" || !math.IsInf(*dec[1].Value, -1) {
+		t.Fatalf("ParseText should decode escaped labels and infinite values, not %+v", dec)
+	}
+	dec[1].Name, dec[1].Value = "temperature", senml.Float(-1)
+	if !exp.Equals(dec) {
+		t.Errorf("ParseText should return %+v not %+v", exp, dec)
+	}
+
+	for _, text := range []string{
+		"{a=\"b\"} 1",
+		"foo",
+		"foo{a=\"b\" 1",
+		"foo{a=b} 1",
+		"foo bar",
+		"foo 1 bar",
+		"foo 1 2 3",
+		"# TYPE foo histogram\nfoo 1",
+	} {
+		if _, err := (Converter{}).ParseText(strings.NewReader(text)); err == nil {
+			t.Errorf("ParseText of %s should return an error", text)
+		}
+	}
+}
+
+func TestTimeSeries(t *testing.T) {
+	conv := Converter{NameLabel: "senml_name"}
+	ts, err := conv.TimeSeries(pack, time.Now())
+	if err != nil {
+		t.Fatalf("TimeSeries returned an error : %s", err)
+	}
+	exp := []TimeSeries{
+		{
+			Labels:  []Label{{Name: "__name__", Value: "urn_dev_ow_10e2073a01080063_temp_celsius"}, {Name: "senml_name", Value: "urn:dev:ow:10e2073a01080063:temp"}},
+			Samples: []Sample{{Value: 23.1, Timestamp: 1320067464000}, {Value: 23.4, Timestamp: 1320067524000}},
+		},
+		{
+			Labels:  []Label{{Name: "__name__", Value: "urn_dev_ow_10e2073a01080063_energy_joules_total"}, {Name: "senml_name", Value: "urn:dev:ow:10e2073a01080063:energy"}},
+			Samples: []Sample{{Value: 1200, Timestamp: 1320067464000}},
+		},
+		{
+			Labels:  []Label{{Name: "__name__", Value: "urn_dev_ow_10e2073a01080064_temp_celsius"}, {Name: "senml_name", Value: "urn:dev:ow:10e2073a01080064:temp"}},
+			Samples: []Sample{{Value: 21.2, Timestamp: 1320067524000}},
+		},
+	}
+	if !reflect.DeepEqual(ts, exp) {
+		t.Errorf("TimeSeries should return %+v not %+v", exp, ts)
+	}
+
+	p := conv.FromTimeSeries(append(ts, TimeSeries{Samples: []Sample{{Value: 1}}}))
+	norm := senml.Pack{pack[0], pack[3], pack[1], pack[4]}
+	if !norm.Equals(p) {
+		t.Errorf("FromTimeSeries should return %+v not %+v", norm, p)
+	}
+
+	now := time.Date(2011, 10, 31, 13, 24, 24, 0, time.UTC)
+	collision := senml.Pack{
+		{Name: "temp:1", Unit: senml.Celsius, Value: senml.Float(23.1)},
+		{Name: "temp/1", Unit: senml.Celsius, Time: 1.320067524e+09, Value: senml.Float(21.2)},
+	}
+	if _, err := (Converter{}).TimeSeries(collision, now); err == nil {
+		t.Errorf("TimeSeries of names converted to the same metric name should return an error")
+	}
+	ts, err = conv.TimeSeries(collision, now)
+	exp = []TimeSeries{
+		{
+			Labels:  []Label{{Name: "__name__", Value: "temp_1_celsius"}, {Name: "senml_name", Value: "temp:1"}},
+			Samples: []Sample{{Value: 23.1, Timestamp: 1320067464000}},
+		},
+		{
+			Labels:  []Label{{Name: "__name__", Value: "temp_1_celsius"}, {Name: "senml_name", Value: "temp/1"}},
+			Samples: []Sample{{Value: 21.2, Timestamp: 1320067524000}},
+		},
+	}
+	if err != nil || !reflect.DeepEqual(ts, exp) {
+		t.Errorf("TimeSeries should return %+v not %+v (%v)", exp, ts, err)
+	}
+}
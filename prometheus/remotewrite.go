@@ -0,0 +1,89 @@
+package prometheus
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/objenious/senml"
+)
+
+// Label is a remote write label. It mirrors prompb.Label.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a remote write sample, with a timestamp in milliseconds. It mirrors prompb.Sample.
+type Sample struct {
+	Value     float64
+	Timestamp int64
+}
+
+// TimeSeries is a remote write time series. It mirrors prompb.TimeSeries,
+// so that it can be copied to a prompb.WriteRequest without this package depending on Prometheus.
+type TimeSeries struct {
+	Labels  []Label
+	Samples []Sample
+}
+
+// TimeSeries converts a normalized pack to remote write time series. Records without time are sampled at now,
+// as remote write requires timestamps. All samples are kept, sorted by timestamp, and labels are sorted by name.
+// Without NameLabel, an error is returned if different SenML names are converted to the same metric name, as in WriteText.
+func (c Converter) TimeSeries(p senml.Pack, now time.Time) ([]TimeSeries, error) {
+	var keys []string
+	series := map[string]*TimeSeries{}
+	senmlNames := map[string]string{}
+	for _, m := range c.metrics(p) {
+		key := m.name
+		if c.NameLabel != "" {
+			key += "\xff" + m.senmlName
+		} else if n, found := senmlNames[m.name]; found && n != m.senmlName {
+			return nil, collisionError(n, m.senmlName, m.name)
+		}
+		senmlNames[m.name] = m.senmlName
+		ts, found := series[key]
+		if !found {
+			ts = &TimeSeries{Labels: []Label{{Name: MetricNameLabel, Value: m.name}}}
+			if c.NameLabel != "" {
+				ts.Labels = append(ts.Labels, Label{Name: c.NameLabel, Value: m.senmlName})
+				sort.Slice(ts.Labels, func(i, j int) bool { return ts.Labels[i].Name < ts.Labels[j].Name })
+			}
+			series[key] = ts
+			keys = append(keys, key)
+		}
+		if m.timestamp == 0 {
+			m.timestamp = now.UnixNano() / 1e6
+		}
+		ts.Samples = append(ts.Samples, Sample{Value: m.value, Timestamp: m.timestamp})
+	}
+	res := make([]TimeSeries, 0, len(keys))
+	for _, key := range keys {
+		ts := series[key]
+		sort.SliceStable(ts.Samples, func(i, j int) bool { return ts.Samples[i].Timestamp < ts.Samples[j].Timestamp })
+		res = append(res, *ts)
+	}
+	return res, nil
+}
+
+// FromTimeSeries converts remote write time series to a pack.
+// As remote write does not carry metric types, samples of metrics with a "_total" suffix are converted to sums,
+// and all other samples to values. Time series without a metric name are ignored.
+func (c Converter) FromTimeSeries(series []TimeSeries) senml.Pack {
+	p := senml.Pack{}
+	for _, ts := range series {
+		labels := make(map[string]string, len(ts.Labels))
+		for _, l := range ts.Labels {
+			labels[l.Name] = l.Value
+		}
+		name := labels[MetricNameLabel]
+		if name == "" {
+			continue
+		}
+		counter := strings.HasSuffix(name, "_total")
+		for _, s := range ts.Samples {
+			p = append(p, c.record(name, counter, labels, s.Value, s.Timestamp))
+		}
+	}
+	return p
+}
@@ -0,0 +1,201 @@
+package prometheus
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/objenious/senml"
+)
+
+// ErrInvalidText is returned when parsing a malformed text exposition.
+var ErrInvalidText = errors.New("prometheus: invalid text exposition")
+
+// WriteText writes a normalized pack in the text exposition format.
+// Samples of the same metric are grouped, and only the most recent sample of each time series is written,
+// as the exposition format does not allow duplicate series.
+// Without NameLabel, an error is returned if different SenML names are converted to the same metric name
+// (e.g. "temp:1" and "temp/1"), as their samples would be merged.
+func (c Converter) WriteText(w io.Writer, p senml.Pack) error {
+	var names []string
+	families := map[string][]metric{}
+	for _, m := range c.metrics(p) {
+		f, found := families[m.name]
+		if !found {
+			names = append(names, m.name)
+		}
+		replaced := false
+		for i := range f {
+			if f[i].senmlName != m.senmlName && c.NameLabel == "" {
+				return collisionError(f[i].senmlName, m.senmlName, m.name)
+			}
+			if f[i].senmlName == m.senmlName {
+				if m.timestamp >= f[i].timestamp {
+					f[i] = m
+				}
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			f = append(f, m)
+		}
+		families[m.name] = f
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		f := families[name]
+		typ := "gauge"
+		if f[0].counter {
+			typ = "counter"
+		}
+		fmt.Fprintf(bw, "# TYPE %s %s\n", name, typ)
+		for _, m := range f {
+			bw.WriteString(name)
+			if c.NameLabel != "" {
+				fmt.Fprintf(bw, `{%s="%s"}`, c.NameLabel, escapeLabel(m.senmlName))
+			}
+			bw.WriteByte(' ')
+			bw.WriteString(formatFloat(m.value))
+			if m.timestamp != 0 {
+				bw.WriteByte(' ')
+				bw.WriteString(strconv.FormatInt(m.timestamp, 10))
+			}
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
+
+// collisionError is returned when different SenML names are converted to the same metric name, without NameLabel.
+func collisionError(senmlName1, senmlName2, name string) error {
+	return fmt.Errorf("prometheus: %s and %s are both converted to %s, set NameLabel to distinguish them", senmlName1, senmlName2, name)
+}
+
+// ParseText reads metrics in the text exposition format, and converts them to a pack.
+// Samples of untyped metrics are converted to values, unless their name ends with "_total".
+// Histograms and summaries are not supported.
+func (c Converter) ParseText(r io.Reader) (senml.Pack, error) {
+	types := map[string]string{}
+	p := senml.Pack{}
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			fields := strings.Fields(line)
+			if len(fields) >= 4 && fields[1] == "TYPE" {
+				types[fields[2]] = fields[3]
+			}
+			continue
+		}
+		name, labels, value, timestamp, err := parseSample(line)
+		if err != nil {
+			return nil, err
+		}
+		counter := false
+		switch types[name] {
+		case "counter":
+			counter = true
+		case "gauge":
+		case "", "untyped":
+			counter = strings.HasSuffix(name, "_total")
+		default:
+			return nil, fmt.Errorf("prometheus: unsupported metric type %s for %s", types[name], name)
+		}
+		p = append(p, c.record(name, counter, labels, value, timestamp))
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func parseSample(line string) (name string, labels map[string]string, value float64, timestamp int64, err error) {
+	i := strings.IndexAny(line, "{ ")
+	if i <= 0 {
+		return "", nil, 0, 0, ErrInvalidText
+	}
+	name, line = line[:i], line[i:]
+	labels = map[string]string{}
+	if line[0] == '{' {
+		if line, err = parseLabels(line[1:], labels); err != nil {
+			return "", nil, 0, 0, err
+		}
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 1 || len(fields) > 2 {
+		return "", nil, 0, 0, ErrInvalidText
+	}
+	if value, err = strconv.ParseFloat(fields[0], 64); err != nil {
+		return "", nil, 0, 0, ErrInvalidText
+	}
+	if len(fields) == 2 {
+		if timestamp, err = strconv.ParseInt(fields[1], 10, 64); err != nil {
+			return "", nil, 0, 0, ErrInvalidText
+		}
+	}
+	return name, labels, value, timestamp, nil
+}
+
+// parseLabels parses labels up to the closing brace, and returns the rest of the line.
+func parseLabels(s string, labels map[string]string) (string, error) {
+	for {
+		s = strings.TrimLeft(s, " ,")
+		if strings.HasPrefix(s, "}") {
+			return s[1:], nil
+		}
+		i := strings.Index(s, "=")
+		if i <= 0 || len(s) < i+2 || s[i+1] != '"' {
+			return "", ErrInvalidText
+		}
+		key := strings.TrimSpace(s[:i])
+		s = s[i+2:]
+		var value []byte
+		closed := false
+		for j := 0; j < len(s); j++ {
+			if s[j] == '\\' && j+1 < len(s) {
+				j++
+				if s[j] == 'n' {
+					value = append(value, '\n')
+				} else {
+					value = append(value, s[j])
+				}
+				continue
+			}
+			if s[j] == '"' {
+				s = s[j+1:]
+				closed = true
+				break
+			}
+			value = append(value, s[j])
+		}
+		if !closed {
+			return "", ErrInvalidText
+		}
+		labels[key] = string(value)
+	}
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -1,6 +1,10 @@
 package senml
 
-import "time"
+import (
+	"bytes"
+	"math"
+	"time"
+)
 
 // Record is a SenML Record.
 type Record struct {
@@ -25,57 +29,50 @@ type Record struct {
 	Sum         *float64 `json:"s,omitempty"  xml:"s,attr,omitempty"`
 }
 
-// Equals checks if two records are equal
+// Equals checks if two records are equal : all fields must be equal, NaN values being equal to each other,
+// and a nil data value being equal to an empty one, as neither is encoded.
 func (r *Record) Equals(r2 *Record) bool {
-	if (r == nil && r2 != nil) || (r != nil && r2 == nil) {
-		return false
-	}
-	if r.BaseName != r2.BaseName {
-		return false
-	}
-	if r.BaseTime != r2.BaseTime {
-		return false
-	}
-	if r.BaseUnit != r2.BaseUnit {
-		return false
-	}
-	if r.BaseVersion != r2.BaseVersion {
-		return false
-	}
-	if r.Name != r2.Name {
-		return false
-	}
-	if r.Unit != r2.Unit {
-		return false
-	}
-	if r.Time != r2.Time {
-		return false
-	}
-	if r.UpdateTime != r2.UpdateTime {
-		return false
-	}
+	return r.EqualsWithin(r2, 0, 0)
+}
 
-	if r.Value != nil && r2.Value != nil && *r.Value == *r2.Value {
-		return true
+// EqualsWithin checks if two records are equal, like Equals, except that numeric values
+// (bv, bs, v and s) may differ by up to tolerance, and times (bt, t and ut) by up to timeTolerance.
+func (r *Record) EqualsWithin(r2 *Record, tolerance, timeTolerance float64) bool {
+	if r == nil || r2 == nil {
+		return r == r2
 	}
-	if r.StringValue != "" && r.StringValue == r2.StringValue {
+	return r.BaseName == r2.BaseName &&
+		floatWithin(r.BaseTime, r2.BaseTime, timeTolerance) &&
+		r.BaseUnit == r2.BaseUnit &&
+		floatPtrWithin(r.BaseValue, r2.BaseValue, tolerance) &&
+		floatPtrWithin(r.BaseSum, r2.BaseSum, tolerance) &&
+		r.BaseVersion == r2.BaseVersion &&
+		r.Name == r2.Name &&
+		r.Unit == r2.Unit &&
+		floatWithin(r.Time, r2.Time, timeTolerance) &&
+		floatWithin(r.UpdateTime, r2.UpdateTime, timeTolerance) &&
+		floatPtrWithin(r.Value, r2.Value, tolerance) &&
+		r.StringValue == r2.StringValue &&
+		bytes.Equal(r.DataValue, r2.DataValue) &&
+		((r.BoolValue == nil && r2.BoolValue == nil) || (r.BoolValue != nil && r2.BoolValue != nil && *r.BoolValue == *r2.BoolValue)) &&
+		floatPtrWithin(r.Sum, r2.Sum, tolerance)
+}
+
+func floatWithin(f1, f2, tolerance float64) bool {
+	if f1 == f2 {
 		return true
 	}
-	if r.BoolValue != nil && r2.BoolValue != nil && *r.BoolValue == *r2.BoolValue {
-		return true
+	if math.IsNaN(f1) || math.IsNaN(f2) {
+		return math.IsNaN(f1) && math.IsNaN(f2)
 	}
-	if r.Sum != nil && r2.Sum != nil && *r.Sum == *r2.Sum {
-		return true
-	}
-	if r.DataValue != nil && r2.DataValue != nil && len(r.DataValue) == len(r2.DataValue) {
-		for i := range r.DataValue {
-			if r.DataValue[i] != r2.DataValue[i] {
-				return false
-			}
-		}
-		return true
+	return math.Abs(f1-f2) <= tolerance
+}
+
+func floatPtrWithin(f1, f2 *float64, tolerance float64) bool {
+	if f1 == nil || f2 == nil {
+		return f1 == f2
 	}
-	return false
+	return floatWithin(*f1, *f2, tolerance)
 }
 
 // GoTime returns the Time of the Record as a Go time.Time.
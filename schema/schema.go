@@ -0,0 +1,145 @@
+// Package schema provides machine-readable CDDL and JSON Schema documents describing the JSON representation
+// of SenML packs (see senml.cddl and senml.schema.json), and validates raw JSON payloads against the JSON Schema,
+// without decoding them to a senml.Pack.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// CDDL is the content of senml.cddl.
+const CDDL = `; CDDL of SenML packs, from https://tools.ietf.org/html/rfc8428#section-11,
+; restricted to the JSON representation used by github.com/objenious/senml.
+
+SenML-Pack = [1* record]
+
+record = {
+  ? bn => tstr,         ; Base Name
+  ? bt => numeric,      ; Base Time
+  ? bu => tstr,         ; Base Units
+  ? bv => numeric,      ; Base Value
+  ? bs => numeric,      ; Base Sum
+  ? bver => uint,       ; Base Version
+  ? n => tstr,          ; Name
+  ? u => tstr,          ; Units
+  ? s => numeric,       ; Sum
+  ? t => numeric,       ; Time
+  ? ut => numeric,      ; Update Time
+  ? ( v => numeric //   ; Numeric Value
+      vs => tstr //     ; String Value
+      vb => bool //     ; Boolean Value
+      vd => binary-value ) ; Data Value
+  * key-value-pair
+}
+
+; extension labels, labels ending with "_" must be understood and are rejected
+key-value-pair = ( label => value )
+label = tstr .regexp "[A-Za-z0-9]([-_:.A-Za-z0-9]*[-:.A-Za-z0-9])?"
+value = tstr / numeric / bool
+
+numeric = number
+binary-value = tstr .regexp "[A-Za-z0-9+/_-]*={0,2}" ; base64 encoded
+
+bn = "bn"
+bt = "bt"
+bu = "bu"
+bv = "bv"
+bs = "bs"
+bver = "bver"
+n = "n"
+u = "u"
+s = "s"
+t = "t"
+ut = "ut"
+v = "v"
+vs = "vs"
+vb = "vb"
+vd = "vd"
+`
+
+// JSONSchema is the content of senml.schema.json.
+const JSONSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "$id": "https://github.com/objenious/senml/schema/senml.schema.json",
+  "title": "SenML Pack",
+  "description": "JSON representation of SenML packs, as defined in RFC 8428.",
+  "type": "array",
+  "minItems": 1,
+  "items": {
+    "type": "object",
+    "properties": {
+      "bn": {"type": "string"},
+      "bt": {"type": "number"},
+      "bu": {"type": "string"},
+      "bv": {"type": "number"},
+      "bs": {"type": "number"},
+      "bver": {"type": "integer", "minimum": 0},
+      "n": {"type": "string"},
+      "u": {"type": "string"},
+      "s": {"type": "number"},
+      "t": {"type": "number"},
+      "ut": {"type": "number"},
+      "v": {"type": "number"},
+      "vs": {"type": "string"},
+      "vb": {"type": "boolean"},
+      "vd": {"type": "string", "pattern": "^[A-Za-z0-9+/_-]*={0,2}$"}
+    },
+    "patternProperties": {
+      "^[A-Za-z0-9]([-_:.A-Za-z0-9]*[-:.A-Za-z0-9])?$": {"type": ["string", "number", "boolean"]}
+    },
+    "additionalProperties": false,
+    "not": {
+      "anyOf": [
+        {"required": ["v", "vs"]},
+        {"required": ["v", "vb"]},
+        {"required": ["v", "vd"]},
+        {"required": ["vs", "vb"]},
+        {"required": ["vs", "vd"]},
+        {"required": ["vb", "vd"]}
+      ]
+    }
+  }
+}
+`
+
+// ErrInvalidJSON is returned when validating data which is not valid JSON.
+var ErrInvalidJSON = errors.New("schema: invalid JSON")
+
+// ValidationError is returned when validating JSON data which does not match the schema.
+type ValidationError struct {
+	// Path is the JSON pointer (RFC 6901) of the invalid value, e.g. "/0/v".
+	Path string
+	// Message describes why the value is invalid.
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	return "schema: " + path + " " + e.Message
+}
+
+var packSchema = mustParse(JSONSchema)
+
+// Validate checks that data is a SenML pack in JSON matching JSONSchema.
+// It returns ErrInvalidJSON if data is not valid JSON, or a *ValidationError.
+func Validate(data []byte) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return ErrInvalidJSON
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return ErrInvalidJSON
+	}
+	if err := packSchema.validate(v, ""); err != nil {
+		return err
+	}
+	return nil
+}
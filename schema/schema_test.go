@@ -0,0 +1,81 @@
+package schema
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/objenious/senml"
+)
+
+func TestDocuments(t *testing.T) {
+	for file, content := range map[string]string{"senml.cddl": CDDL, "senml.schema.json": JSONSchema} {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatalf("reading %s returned an error : %s", file, err)
+		}
+		if string(b) != content {
+			t.Errorf("%s is out of sync with its constant", file)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	// packs encoded by the senml package are valid
+	p := senml.Pack{
+		{BaseName: "urn:dev:ow:10e2073a01080063:", BaseTime: 1.320067464e+09, BaseUnit: senml.Celsius, BaseValue: senml.Float(1), BaseSum: senml.Float(2), BaseVersion: 10, Name: "temp", Value: senml.Float(23.1)},
+		{Name: "energy", Unit: senml.Joule, Time: -60, UpdateTime: 300, Sum: senml.Float(1200)},
+		{Name: "open", BoolValue: senml.False},
+		{Name: "label", StringValue: "kitchen"},
+		{Name: "raw", DataValue: []byte{0xca, 0xfe, 0xff}},
+	}
+	b, _ := json.Marshal(p)
+	if err := Validate(b); err != nil {
+		t.Errorf("validation of %s returned an error : %s", b, err)
+	}
+
+	tcs := []struct {
+		src string
+		err string
+	}{
+		// RFC 8428 examples
+		{src: `[{"n":"urn:dev:ow:10e2073a01080063","u":"Cel","v":23.1}]`},
+		{src: `[{"bn":"urn:dev:ow:10e2073a0108006:","bt":1.276020076001e+09,"bu":"A","bver":5,"n":"voltage","u":"V","v":120.1},{"n":"current","t":-5,"v":1.2},{"n":"current","t":-4,"v":1.3}]`},
+		{src: `[{"bn":"urn:dev:ow:10e2073a01080063:","n":"temp","v":23.1,"u":"Cel","t":1.276020076e+09},{"n":"raw","vd":"AQID"}]`},
+		// extensions
+		{src: `[{"n":"temp","v":23.1,"foo":"bar","bar-baz":1}]`},
+		{src: ` [ {"bver":1.0} ] `},
+
+		{src: `[]`, err: "schema: / should have at least 1 items"},
+		{src: `{}`, err: "schema: / should be of type array"},
+		{src: `[1]`, err: "schema: /0 should be of type object"},
+		{src: `[{"v":"1"}]`, err: "schema: /0/v should be of type number"},
+		{src: `[{"bver":-1}]`, err: "schema: /0/bver should be at least 0"},
+		{src: `[{"bver":1.5}]`, err: "schema: /0/bver should be of type integer"},
+		{src: `[{"n":"a"},{"vb":1}]`, err: "schema: /1/vb should be of type boolean"},
+		{src: `[{"vd":"!"}]`, err: "schema: /0/vd should match ^[A-Za-z0-9+/_-]*={0,2}$"},
+		{src: `[{"foo_":1}]`, err: "schema: /0/foo_ is not an allowed property"},
+		{src: `[{"a/b":1}]`, err: "schema: /0/a~1b is not an allowed property"},
+		{src: `[{"foo":null}]`, err: "schema: /0/foo should be of type string or number or boolean"},
+		{src: `[{"v":1,"vs":"a"}]`, err: "schema: /0 should not have all of the v, vs properties"},
+		{src: `[{"vb":true,"vd":""}]`, err: "schema: /0 should not have all of the vb, vd properties"},
+	}
+	for _, tc := range tcs {
+		err := Validate([]byte(tc.src))
+		if tc.err == "" {
+			if err != nil {
+				t.Errorf("validation of %s returned an error : %s", tc.src, err)
+			}
+			continue
+		}
+		if _, ok := err.(*ValidationError); !ok || err.Error() != tc.err {
+			t.Errorf("validation of %s should return %q not %v", tc.src, tc.err, err)
+		}
+	}
+
+	for _, src := range []string{``, `[`, `[] []`, `[{"v":1,}]`} {
+		if err := Validate([]byte(src)); err != ErrInvalidJSON {
+			t.Errorf("validation of %s should return ErrInvalidJSON, not %v", src, err)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// node is a JSON Schema. Only the keywords used by JSONSchema are supported.
+type node struct {
+	Type                 types            `json:"type,omitempty"`
+	Properties           map[string]*node `json:"properties,omitempty"`
+	PatternProperties    map[string]*node `json:"patternProperties,omitempty"`
+	AdditionalProperties *bool            `json:"additionalProperties,omitempty"`
+	Required             []string         `json:"required,omitempty"`
+	Items                *node            `json:"items,omitempty"`
+	MinItems             *int             `json:"minItems,omitempty"`
+	Minimum              *float64         `json:"minimum,omitempty"`
+	Pattern              string           `json:"pattern,omitempty"`
+	Not                  *node            `json:"not,omitempty"`
+	AnyOf                []*node          `json:"anyOf,omitempty"`
+
+	pattern           *regexp.Regexp
+	patternProperties map[*regexp.Regexp]*node
+}
+
+// types is the "type" keyword, either a string or an array of strings.
+type types []string
+
+func (t *types) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*t = types{s}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(t))
+}
+
+func mustParse(s string) *node {
+	n := &node{}
+	if err := json.Unmarshal([]byte(s), n); err != nil {
+		panic(err)
+	}
+	n.compile()
+	return n
+}
+
+// compile compiles the regular expressions of the schema and its sub-schemas.
+func (n *node) compile() {
+	if n.Pattern != "" {
+		n.pattern = regexp.MustCompile(n.Pattern)
+	}
+	n.patternProperties = map[*regexp.Regexp]*node{}
+	for p, sub := range n.PatternProperties {
+		n.patternProperties[regexp.MustCompile(p)] = sub
+	}
+	for _, sub := range n.Properties {
+		sub.compile()
+	}
+	for _, sub := range n.PatternProperties {
+		sub.compile()
+	}
+	for _, sub := range n.AnyOf {
+		sub.compile()
+	}
+	if n.Items != nil {
+		n.Items.compile()
+	}
+	if n.Not != nil {
+		n.Not.compile()
+	}
+}
+
+// validate checks a value decoded with json.Decoder.UseNumber.
+func (n *node) validate(v interface{}, path string) *ValidationError {
+	fail := func(format string, args ...interface{}) *ValidationError {
+		return &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)}
+	}
+	if len(n.Type) > 0 {
+		found := false
+		for _, t := range n.Type {
+			found = found || hasType(v, t)
+		}
+		if !found {
+			return fail("should be of type %s", strings.Join(n.Type, " or "))
+		}
+	}
+	switch v := v.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		if n.Minimum != nil && f < *n.Minimum {
+			return fail("should be at least %v", *n.Minimum)
+		}
+	case string:
+		if n.pattern != nil && !n.pattern.MatchString(v) {
+			return fail("should match %s", n.Pattern)
+		}
+	case []interface{}:
+		if n.MinItems != nil && len(v) < *n.MinItems {
+			return fail("should have at least %d items", *n.MinItems)
+		}
+		if n.Items != nil {
+			for i, item := range v {
+				if err := n.Items.validate(item, path+"/"+strconv.Itoa(i)); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]interface{}:
+		for _, key := range n.Required {
+			if _, found := v[key]; !found {
+				return fail("should have a %q property", key)
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			p := path + "/" + escape(key)
+			matched := false
+			if sub, found := n.Properties[key]; found {
+				matched = true
+				if err := sub.validate(v[key], p); err != nil {
+					return err
+				}
+			}
+			for rx, sub := range n.patternProperties {
+				if rx.MatchString(key) {
+					matched = true
+					if err := sub.validate(v[key], p); err != nil {
+						return err
+					}
+				}
+			}
+			if !matched && n.AdditionalProperties != nil && !*n.AdditionalProperties {
+				return &ValidationError{Path: p, Message: "is not an allowed property"}
+			}
+		}
+	}
+	if len(n.AnyOf) > 0 {
+		var first *ValidationError
+		for _, sub := range n.AnyOf {
+			err := sub.validate(v, path)
+			if err == nil {
+				first = nil
+				break
+			}
+			if first == nil {
+				first = err
+			}
+		}
+		if first != nil {
+			return first
+		}
+	}
+	if n.Not != nil && n.Not.validate(v, path) == nil {
+		// describe the matching branch, e.g. the properties which should not be used together
+		for _, sub := range n.Not.AnyOf {
+			if len(sub.Required) > 0 && sub.validate(v, path) == nil {
+				return fail("should not have all of the %s properties", strings.Join(sub.Required, ", "))
+			}
+		}
+		return fail("should not match %s", n.Not)
+	}
+	return nil
+}
+
+func (n *node) String() string {
+	b, _ := json.Marshal(n)
+	return string(b)
+}
+
+func hasType(v interface{}, t string) bool {
+	switch v := v.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case string:
+		return t == "string"
+	case []interface{}:
+		return t == "array"
+	case map[string]interface{}:
+		return t == "object"
+	case json.Number:
+		if t == "integer" {
+			f, err := v.Float64()
+			return err == nil && f == math.Trunc(f)
+		}
+		return t == "number"
+	}
+	return false
+}
+
+// escape escapes a key for use in a JSON pointer.
+func escape(key string) string {
+	return strings.Replace(strings.Replace(key, "~", "~0", -1), "/", "~1", -1)
+}
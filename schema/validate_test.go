@@ -0,0 +1,46 @@
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNode(t *testing.T) {
+	n := mustParse(`{"anyOf":[{"type":"string"},{"type":"object","required":["a~b"],"not":{"type":"object","properties":{"a~b":{"type":"null"}}}}]}`)
+	tcs := []struct {
+		src string
+		err string
+	}{
+		{src: `"foo"`},
+		{src: `{"a~b":1}`},
+		{src: `1`, err: "schema: / should be of type string"},
+		// the error of the first branch of anyOf is returned
+		{src: `{"a~b":null}`, err: "schema: / should be of type string"},
+		{src: `{}`, err: "schema: / should be of type string"},
+	}
+	for _, tc := range tcs {
+		d := json.NewDecoder(strings.NewReader(tc.src))
+		d.UseNumber()
+		var v interface{}
+		if err := d.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		err := n.validate(v, "")
+		if tc.err == "" {
+			if err != nil {
+				t.Errorf("validation of %s returned an error : %s", tc.src, err)
+			}
+		} else if err == nil || err.Error() != tc.err {
+			t.Errorf("validation of %s should return %q not %v", tc.src, tc.err, err)
+		}
+	}
+	n = mustParse(`{"not":{"type":"object","properties":{"a~b":{"type":"null"}}}}`)
+	exp := `schema: / should not match {"type":["object"],"properties":{"a~b":{"type":["null"]}}}`
+	if err := n.validate(map[string]interface{}{"a~b": nil}, ""); err == nil || err.Error() != exp {
+		t.Errorf("validation should return %q not %v", exp, err)
+	}
+	if escape("a~b/c") != "a~0b~1c" {
+		t.Errorf("escaping of a~b/c should be a~0b~1c not %s", escape("a~b/c"))
+	}
+}
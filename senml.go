@@ -3,14 +3,21 @@ package senml
 import (
 	"encoding/xml"
 	"sort"
+	"strconv"
 	"time"
 )
 
 // Pack defines a SenML pack (a list of Records).
 type Pack []Record
 
-// Equals checks if 2 packs are equal.
+// Equals checks if 2 packs are equal, i.e. have equal records in the same order (see Record.Equals).
 func (p Pack) Equals(p2 Pack) bool {
+	return p.EqualsWithin(p2, 0, 0)
+}
+
+// EqualsWithin checks if 2 packs have equal records in the same order, with numeric values differing
+// by up to tolerance and times by up to timeTolerance (see Record.EqualsWithin).
+func (p Pack) EqualsWithin(p2 Pack, tolerance, timeTolerance float64) bool {
 	if (p == nil && p2 != nil) || (p != nil && p2 == nil) {
 		return false
 	}
@@ -18,7 +25,7 @@ func (p Pack) Equals(p2 Pack) bool {
 		return false
 	}
 	for i := range p {
-		if !p[i].Equals(&p2[i]) {
+		if !p[i].EqualsWithin(&p2[i], tolerance, timeTolerance) {
 			return false
 		}
 	}
@@ -26,8 +33,73 @@ func (p Pack) Equals(p2 Pack) bool {
 }
 
 // Normalize resolves the SenML Records, as explained in https://tools.ietf.org/html/draft-ietf-core-senml-16#section-4.6.
-// All base items are removed, and records are sorted in chronological order.
+// All base items are removed, and records are sorted in chronological order, records with the same time
+// keeping their order in the pack.
 func (p Pack) Normalize() Pack {
+	n, _ := p.normalize(&NormalizeOptions{NonFinite: keepNonFinite})
+	return n
+}
+
+// Order is the order of normalized records.
+type Order int
+
+const (
+	// ByTime sorts records in chronological order, records with the same time keeping their order.
+	ByTime Order = iota
+	// ByTimeThenName sorts records in chronological order, then by name.
+	ByTimeThenName
+	// InputOrder keeps the order of the records in the pack.
+	InputOrder
+)
+
+// NormalizeOptions define how packs are normalized. The zero value normalizes like Normalize,
+// except for non-finite numbers.
+type NormalizeOptions struct {
+	// Order is the order of the normalized records.
+	Order Order
+	// NonFinite is the policy for non-finite resolved values and times. A *NonFiniteError
+	// holds the index of the record in the original pack. NullNonFinite and StringNonFinite
+	// keep non-finite values, like Normalize : the policy of the encoding applies.
+	NonFinite NonFinitePolicy
+	// ReferenceTime returns the time relative times are resolved against, e.g. time.Now,
+	// or the reception time of a buffered pack. If nil, relative times are kept.
+	ReferenceTime func() time.Time
+	// MaxRelativeTime is the largest relative time, DefaultMaxRelativeTime if zero.
+	MaxRelativeTime float64
+	// DropBaseVersion removes the base version from the records, instead of copying it to each record.
+	DropBaseVersion bool
+	// RejectNoValue returns a *NoValueError for records without value, instead of dropping them.
+	// Records holding only base fields (and no name, unit nor time) are always dropped.
+	RejectNoValue bool
+}
+
+// NoValueError is returned by NormalizeWith for records without value, if RejectNoValue is set.
+type NoValueError struct {
+	// Record is the index of the record in the pack.
+	Record int
+}
+
+func (e *NoValueError) Error() string {
+	return "senml: record " + strconv.Itoa(e.Record) + ": no value"
+}
+
+// NormalizeWith resolves the SenML Records like Normalize, with options.
+func (p Pack) NormalizeWith(opts NormalizeOptions) (Pack, error) {
+	return p.normalize(&opts)
+}
+
+// normalize resolves the records.
+func (p Pack) normalize(opts *NormalizeOptions) (Pack, error) {
+	var ref float64
+	hasRef := opts.ReferenceTime != nil
+	if hasRef {
+		ref = Time(opts.ReferenceTime())
+	}
+	maxRelative := opts.MaxRelativeTime
+	if maxRelative == 0 {
+		maxRelative = DefaultMaxRelativeTime
+	}
+
 	var bname string
 	var bunit Unit
 	var btime, bval, bsum float64
@@ -62,6 +134,12 @@ func (p Pack) Normalize() Pack {
 		if p[i].Unit != "" {
 			r.Unit = p[i].Unit
 		}
+		if hasRef {
+			r.Time = absoluteTime(r.Time, ref, maxRelative)
+		}
+		if opts.DropBaseVersion {
+			r.BaseVersion = 0
+		}
 		switch {
 		case p[i].Value != nil:
 			nval := bval + *p[i].Value
@@ -76,22 +154,41 @@ func (p Pack) Normalize() Pack {
 			nsum := bsum + *p[i].Sum
 			r.Sum = &nsum
 		default:
+			if opts.RejectNoValue && (p[i].Name != "" || p[i].Unit != "" || p[i].Time != 0) {
+				return nil, &NoValueError{Record: i}
+			}
+			continue
+		}
+		keep, err := r.checkFinite(i, opts.NonFinite)
+		if err != nil {
+			return nil, err
+		}
+		if !keep {
 			continue
 		}
 		n = append(n, r)
 	}
-	sort.Sort(&n)
-	return n
+	switch opts.Order {
+	case ByTime:
+		sort.Stable(n)
+	case ByTimeThenName:
+		sort.SliceStable(n, func(i, j int) bool {
+			if n[i].Time != n[j].Time {
+				return n[i].Time < n[j].Time
+			}
+			return n[i].Name < n[j].Name
+		})
+	}
+	return n, nil
 }
 
 // NormalizeAt resolves the SenML Records, and replaces all relative times
 // by absolute times, based on the t reference time.
 func (p Pack) NormalizeAt(t time.Time) Pack {
-	n := p.Normalize()
-	rt := Time(t)
-	for i := range n {
-		n[i].Time = absoluteTime(n[i].Time, rt)
-	}
+	n, _ := p.normalize(&NormalizeOptions{
+		NonFinite:     keepNonFinite,
+		ReferenceTime: func() time.Time { return t },
+	})
 	return n
 }
 
@@ -111,29 +208,22 @@ func (p Pack) Swap(i, j int) {
 }
 
 // MarshalXML implements xml.Marshaler. It encodes the SenML Pack to XML.
+// A *NonFiniteError is returned if a record holds a non-finite number.
 func (p Pack) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
-	n := xmlPack{
-		Xmlns:   "urn:ietf:params:xml:ns:senml",
-		Records: make([]xmlRecord, len(p)),
-	}
-	for i := range p {
-		n.Records[i].Record = p[i]
+	n, err := p.xmlPack(RejectNonFinite)
+	if err != nil {
+		return err
 	}
 	return e.Encode(n)
 }
 
 // UnmarshalXML implements xml.Unmarshaler. It decodes a XML encoded SenML Pack.
+// The root element must be sensml in the SenML namespace, with only senml child elements,
+// and attribute values are parsed strictly. Use DecodeXML to decode documents from legacy producers.
+// A *XMLError is returned for invalid documents, a *NonFiniteError for non-finite numbers,
+// and a *VersionError for base versions higher than Version.
 func (p *Pack) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
-	n := xmlPack{}
-	err := d.DecodeElement(&n, &start)
-	if err != nil {
-		return err
-	}
-	*p = make(Pack, len(n.Records))
-	for i := range *p {
-		(*p)[i] = n.Records[i].Record
-	}
-	return nil
+	return p.decodeXML(d, start, XMLOptions{})
 }
 
 type xmlPack struct {
@@ -145,4 +235,11 @@ type xmlPack struct {
 type xmlRecord struct {
 	XMLName *bool `json:"_,omitempty" xml:"senml"`
 	Record  `xml:",innerxml"`
+	// numeric values hide the fields of Record, to encode non-finite values as xs:double
+	BaseValue string `xml:"bv,attr,omitempty"`
+	BaseSum   string `xml:"bs,attr,omitempty"`
+	Value     string `xml:"v,attr,omitempty"`
+	// DataValue hides Record.DataValue, to encode it as base64url
+	DataValue string `xml:"vd,attr,omitempty"`
+	Sum       string `xml:"s,attr,omitempty"`
 }
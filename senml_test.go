@@ -3,6 +3,9 @@ package senml
 import (
 	"encoding/json"
 	"encoding/xml"
+	"math"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -229,6 +232,69 @@ func TestEquals(t *testing.T) {
 			},
 			res: false,
 		},
+		{
+			a: Pack{
+				{Name: "foo", DataValue: []byte{}},
+			},
+			b: Pack{
+				{Name: "foo", DataValue: nil},
+			},
+			res: true,
+		},
+		{
+			a: Pack{
+				{BaseName: "foo", BaseTime: 1},
+			},
+			b: Pack{
+				{BaseName: "foo", BaseTime: 1},
+			},
+			res: true,
+		},
+		{
+			a: Pack{
+				{Name: "foo", Value: Float(1), StringValue: "foo"},
+			},
+			b: Pack{
+				{Name: "foo", Value: Float(1), StringValue: "bar"},
+			},
+			res: false,
+		},
+		{
+			a: Pack{
+				{Name: "foo", Value: Float(1), Sum: Float(1)},
+			},
+			b: Pack{
+				{Name: "foo", Value: Float(1)},
+			},
+			res: false,
+		},
+		{
+			a: Pack{
+				{Name: "foo", BaseSum: Float(1)},
+			},
+			b: Pack{
+				{Name: "foo", BaseSum: Float(2)},
+			},
+			res: false,
+		},
+		{
+			a: Pack{
+				{Name: "foo", Value: Float(math.NaN())},
+			},
+			b: Pack{
+				{Name: "foo", Value: Float(math.NaN())},
+			},
+			res: true,
+		},
+		{
+			a: Pack{
+				{Name: "foo", Value: Float(math.NaN())},
+			},
+			b: Pack{
+				{Name: "foo", Value: Float(1)},
+			},
+			res: false,
+		},
 	}
 	for _, tc := range tcs {
 		if tc.a.Equals(tc.b) != tc.res {
@@ -237,6 +303,60 @@ func TestEquals(t *testing.T) {
 	}
 }
 
+func TestEqualsWithin(t *testing.T) {
+	tcs := []struct {
+		a   Record
+		b   Record
+		res bool
+	}{
+		{
+			a:   Record{Name: "foo", Time: 10, Value: Float(1)},
+			b:   Record{Name: "foo", Time: 10.5, Value: Float(1.05)},
+			res: true,
+		},
+		{
+			a:   Record{Name: "foo", Time: 10, Value: Float(1)},
+			b:   Record{Name: "foo", Time: 12, Value: Float(1)},
+			res: false,
+		},
+		{
+			a:   Record{Name: "foo", Value: Float(1)},
+			b:   Record{Name: "foo", Value: Float(1.2)},
+			res: false,
+		},
+		{
+			a:   Record{BaseTime: 100, BaseValue: Float(1), BaseSum: Float(2), Name: "foo", UpdateTime: 60, Sum: Float(3)},
+			b:   Record{BaseTime: 101, BaseValue: Float(0.9), BaseSum: Float(2.05), Name: "foo", UpdateTime: 59, Sum: Float(2.95)},
+			res: true,
+		},
+		{
+			a:   Record{Name: "foo", Sum: Float(1)},
+			b:   Record{Name: "foo", Value: Float(1)},
+			res: false,
+		},
+		{
+			a:   Record{Name: "foo", Value: Float(math.Inf(1))},
+			b:   Record{Name: "foo", Value: Float(math.Inf(1))},
+			res: true,
+		},
+		{
+			a:   Record{Name: "foo", Value: Float(math.NaN())},
+			b:   Record{Name: "foo", Value: Float(1)},
+			res: false,
+		},
+	}
+	for _, tc := range tcs {
+		if res := tc.a.EqualsWithin(&tc.b, 0.1, 1); res != tc.res {
+			t.Errorf("EqualsWithin with %+v and %+v should return %v", tc.a, tc.b, tc.res)
+		}
+	}
+	a := Pack{{Name: "foo", Value: Float(1)}, {Name: "bar", Value: Float(2)}}
+	b := Pack{{Name: "foo", Value: Float(1.01)}, {Name: "bar", Value: Float(1.99)}}
+	if !a.EqualsWithin(b, 0.1, 0) || a.Equals(b) {
+		t.Errorf("%+v and %+v should only be equal within 0.1", a, b)
+	}
+}
+
 func TestNormalize(t *testing.T) {
 	tcs := []struct {
 		src  Pack
@@ -399,6 +519,111 @@ func TestNormalizeAt(t *testing.T) {
 	}
 }
 
+func TestNormalizeWith(t *testing.T) {
+	received := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	src := Pack{
+		{BaseName: "dev:", BaseVersion: 10},
+		{Name: "temp", Time: -60, Value: Float(1)},
+		{Name: "hum", Time: 1000, Value: Float(2)},
+		{Name: "open", Time: 1.6e9, BoolValue: True},
+	}
+	tcs := []struct {
+		opts NormalizeOptions
+		norm Pack
+	}{
+		{
+			opts: NormalizeOptions{},
+			norm: Pack{
+				{Name: "dev:temp", Time: -60, Value: Float(1), BaseVersion: 10},
+				{Name: "dev:hum", Time: 1000, Value: Float(2), BaseVersion: 10},
+				{Name: "dev:open", Time: 1.6e9, BoolValue: True, BaseVersion: 10},
+			},
+		},
+		{
+			opts: NormalizeOptions{ReferenceTime: func() time.Time { return received }, DropBaseVersion: true},
+			norm: Pack{
+				{Name: "dev:temp", Time: Time(received) - 60, Value: Float(1)},
+				{Name: "dev:hum", Time: Time(received) + 1000, Value: Float(2)},
+				{Name: "dev:open", Time: 1.6e9, BoolValue: True},
+			},
+		},
+		{
+			opts: NormalizeOptions{ReferenceTime: func() time.Time { return received }, MaxRelativeTime: 100, DropBaseVersion: true},
+			norm: Pack{
+				{Name: "dev:hum", Time: 1000, Value: Float(2)},
+				{Name: "dev:temp", Time: Time(received) - 60, Value: Float(1)},
+				{Name: "dev:open", Time: 1.6e9, BoolValue: True},
+			},
+		},
+	}
+	for _, tc := range tcs {
+		norm, err := src.NormalizeWith(tc.opts)
+		if err != nil || !norm.Equals(tc.norm) {
+			t.Errorf("Normalized version of %+v with %+v should be %+v not %+v (%v)", src, tc.opts, tc.norm, norm, err)
+		}
+	}
+
+	if _, err := src.NormalizeWith(NormalizeOptions{RejectNoValue: true}); err != nil {
+		t.Errorf("records with only base fields should be dropped, not return %v", err)
+	}
+	src = append(src, Record{Name: "empty"})
+	if norm, err := src.NormalizeWith(NormalizeOptions{}); err != nil || len(norm) != 3 {
+		t.Errorf("records without value should be dropped, not return %+v (%v)", norm, err)
+	}
+	_, err := src.NormalizeWith(NormalizeOptions{RejectNoValue: true})
+	if nv, ok := err.(*NoValueError); !ok || nv.Record != 4 {
+		t.Errorf("records without value should return a *NoValueError for record 4, not %v", err)
+	}
+}
+
+func TestNormalizeOrder(t *testing.T) {
+	src := Pack{
+		{BaseName: "dev:", BaseTime: 100, Name: "temp", Time: 1, Value: Float(1)},
+	}
+	// many records with the same time, to catch unstable sorting
+	for _, name := range []string{"z", "b", "y", "c", "x", "d", "w", "e", "v", "f", "u", "g", "t", "h"} {
+		src = append(src, Record{Name: name, Value: Float(1)})
+	}
+	src = append(src, Record{Name: "temp", Time: -1, Value: Float(1)})
+
+	tcs := []struct {
+		order Order
+		names []string
+	}{
+		{
+			order: ByTime,
+			names: []string{"temp", "z", "b", "y", "c", "x", "d", "w", "e", "v", "f", "u", "g", "t", "h", "temp"},
+		},
+		{
+			order: ByTimeThenName,
+			names: []string{"temp", "b", "c", "d", "e", "f", "g", "h", "t", "u", "v", "w", "x", "y", "z", "temp"},
+		},
+		{
+			order: InputOrder,
+			names: []string{"temp", "z", "b", "y", "c", "x", "d", "w", "e", "v", "f", "u", "g", "t", "h", "temp"},
+		},
+	}
+	for _, tc := range tcs {
+		norm, err := src.NormalizeWith(NormalizeOptions{Order: tc.order})
+		if err != nil {
+			t.Fatalf("NormalizeWith returned an error : %s", err)
+		}
+		names := make([]string, len(norm))
+		for i := range norm {
+			names[i] = strings.TrimPrefix(norm[i].Name, "dev:")
+		}
+		if !reflect.DeepEqual(names, tc.names) {
+			t.Errorf("names of records normalized with order %d should be %v not %v", tc.order, tc.names, names)
+		}
+		if tc.order == InputOrder && (norm[0].Time != 101 || norm[15].Time != 99) {
+			t.Errorf("records normalized with InputOrder should keep their order, not %+v", norm)
+		}
+	}
+	if norm := src.Normalize(); norm[0].Time != 99 || norm[1].Name != "dev:z" || norm[14].Name != "dev:h" || norm[15].Time != 101 {
+		t.Errorf("Normalize should sort records with the same time in their order, not %+v", norm)
+	}
+}
+
 func TestJSON(t *testing.T) {
 	tcs := []struct {
 		src  Pack
@@ -0,0 +1,142 @@
+// Package senmlpb defines a Protocol Buffers representation of SenML packs (see senml.proto),
+// and converts it to and from senml.Pack.
+//
+// The numeric, string, boolean and data values of a record are mapped to the "kind" oneof,
+// the sum is an optional field of its own, as SenML allows a record to hold both a value and a sum.
+package senmlpb
+
+import (
+	"errors"
+
+	"github.com/objenious/senml"
+)
+
+var (
+	// ErrInvalidData is returned when decoding malformed Protocol Buffers data.
+	ErrInvalidData = errors.New("senmlpb: invalid data")
+	// ErrMultipleValues is returned when converting a record holding more than one of v, vs, vb and vd.
+	ErrMultipleValues = errors.New("senmlpb: record with multiple values")
+)
+
+// FromPack converts a pack to its Protocol Buffers representation.
+func FromPack(p senml.Pack) (*Pack, error) {
+	pb := &Pack{Records: make([]*Record, len(p))}
+	for i := range p {
+		r, err := FromRecord(&p[i])
+		if err != nil {
+			return nil, err
+		}
+		pb.Records[i] = r
+	}
+	return pb, nil
+}
+
+// FromRecord converts a record to its Protocol Buffers representation.
+func FromRecord(r *senml.Record) (*Record, error) {
+	pb := &Record{
+		BaseName:    r.BaseName,
+		BaseTime:    r.BaseTime,
+		BaseUnit:    string(r.BaseUnit),
+		BaseValue:   copyFloat(r.BaseValue),
+		BaseSum:     copyFloat(r.BaseSum),
+		BaseVersion: int32(r.BaseVersion),
+		Name:        r.Name,
+		Unit:        string(r.Unit),
+		Time:        r.Time,
+		UpdateTime:  r.UpdateTime,
+		Sum:         copyFloat(r.Sum),
+	}
+	set := func(v isRecord_Kind) error {
+		if pb.Kind != nil {
+			return ErrMultipleValues
+		}
+		pb.Kind = v
+		return nil
+	}
+	if r.Value != nil {
+		if err := set(&Record_Value{Value: *r.Value}); err != nil {
+			return nil, err
+		}
+	}
+	if r.StringValue != "" {
+		if err := set(&Record_StringValue{StringValue: r.StringValue}); err != nil {
+			return nil, err
+		}
+	}
+	if r.BoolValue != nil {
+		if err := set(&Record_BoolValue{BoolValue: *r.BoolValue}); err != nil {
+			return nil, err
+		}
+	}
+	if r.DataValue != nil {
+		if err := set(&Record_DataValue{DataValue: append([]byte{}, r.DataValue...)}); err != nil {
+			return nil, err
+		}
+	}
+	return pb, nil
+}
+
+// ToPack converts the Protocol Buffers representation of a pack to a senml.Pack.
+// Nil records are skipped.
+func (x *Pack) ToPack() senml.Pack {
+	p := make(senml.Pack, 0, len(x.GetRecords()))
+	for _, r := range x.GetRecords() {
+		if r != nil {
+			p = append(p, r.ToRecord())
+		}
+	}
+	return p
+}
+
+// ToRecord converts the Protocol Buffers representation of a record to a senml.Record.
+func (x *Record) ToRecord() senml.Record {
+	r := senml.Record{
+		BaseName:    x.BaseName,
+		BaseTime:    x.BaseTime,
+		BaseUnit:    senml.Unit(x.BaseUnit),
+		BaseValue:   copyFloat(x.BaseValue),
+		BaseSum:     copyFloat(x.BaseSum),
+		BaseVersion: int(x.BaseVersion),
+		Name:        x.Name,
+		Unit:        senml.Unit(x.Unit),
+		Time:        x.Time,
+		UpdateTime:  x.UpdateTime,
+		Sum:         copyFloat(x.Sum),
+	}
+	switch v := x.Kind.(type) {
+	case *Record_Value:
+		r.Value = senml.Float(v.Value)
+	case *Record_StringValue:
+		r.StringValue = v.StringValue
+	case *Record_BoolValue:
+		r.BoolValue = senml.Bool(v.BoolValue)
+	case *Record_DataValue:
+		r.DataValue = append([]byte{}, v.DataValue...)
+	}
+	return r
+}
+
+// Marshal encodes a pack in the Protocol Buffers wire format.
+func Marshal(p senml.Pack) ([]byte, error) {
+	pb, err := FromPack(p)
+	if err != nil {
+		return nil, err
+	}
+	return pb.Marshal()
+}
+
+// Unmarshal decodes a pack encoded in the Protocol Buffers wire format.
+func Unmarshal(b []byte) (senml.Pack, error) {
+	pb := &Pack{}
+	if err := pb.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	return pb.ToPack(), nil
+}
+
+func copyFloat(f *float64) *float64 {
+	if f == nil {
+		return nil
+	}
+	return senml.Float(*f)
+}
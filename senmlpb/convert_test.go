@@ -0,0 +1,46 @@
+package senmlpb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/objenious/senml"
+)
+
+func TestConvert(t *testing.T) {
+	tcs := []senml.Pack{
+		{},
+		{
+			{BaseName: "urn:dev:ow:10e2073a01080063:", BaseTime: 1.320067464e+09, BaseUnit: senml.Celsius, BaseValue: senml.Float(1), BaseSum: senml.Float(2), BaseVersion: 10, Name: "temp", Value: senml.Float(23.1)},
+			{Name: "energy", Unit: senml.Joule, Time: -60, UpdateTime: 300, Value: senml.Float(0), Sum: senml.Float(1200)},
+			{Name: "open", BoolValue: senml.False},
+			{Name: "label", StringValue: "kitchen"},
+			{Name: "raw", DataValue: []byte{0xca, 0xfe}},
+			{Name: "empty", DataValue: []byte{}},
+			{Name: "none"},
+			{Name: "negative", BaseVersion: -1, Value: senml.Float(-1)},
+		},
+	}
+	for _, tc := range tcs {
+		enc, err := Marshal(tc)
+		if err != nil {
+			t.Errorf("encoding of %+v returned an error : %s", tc, err)
+			continue
+		}
+		dec, err := Unmarshal(enc)
+		if err != nil {
+			t.Errorf("decoding of %x returned an error : %s", enc, err)
+			continue
+		}
+		if !reflect.DeepEqual(tc, dec) {
+			t.Errorf("decoding of %x should be %+v not %+v", enc, tc, dec)
+		}
+	}
+
+	if _, err := FromPack(senml.Pack{{Name: "foo", Value: senml.Float(1), StringValue: "bar"}}); err != ErrMultipleValues {
+		t.Errorf("converting a record with several values should return ErrMultipleValues, not %v", err)
+	}
+	if p := (&Pack{Records: []*Record{nil, {Name: "foo"}}}).ToPack(); !reflect.DeepEqual(p, senml.Pack{{Name: "foo"}}) {
+		t.Errorf("converting nil records should skip them, not return %+v", p)
+	}
+}
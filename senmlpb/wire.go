@@ -0,0 +1,424 @@
+package senmlpb
+
+// The types of this file are hand-written, not generated : they follow the naming of the code generated
+// by protoc-gen-go for senml.proto, and are encoded to and decoded from the protobuf wire format without
+// the protobuf runtime, which does not support the Go versions supported by this package.
+// They do not implement proto.Message : gRPC services should use the code generated from senml.proto.
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Pack is a list of SenML records.
+type Pack struct {
+	Records []*Record
+}
+
+// GetRecords returns the records of the pack.
+func (x *Pack) GetRecords() []*Record {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+// Record is a SenML record.
+type Record struct {
+	BaseName    string
+	BaseTime    float64
+	BaseUnit    string
+	BaseValue   *float64
+	BaseSum     *float64
+	BaseVersion int32
+
+	Name       string
+	Unit       string
+	Time       float64
+	UpdateTime float64
+
+	// Types that are valid to be assigned to Kind:
+	//	*Record_Value
+	//	*Record_StringValue
+	//	*Record_BoolValue
+	//	*Record_DataValue
+	Kind isRecord_Kind
+	Sum  *float64
+}
+
+type isRecord_Kind interface {
+	isRecord_Kind()
+}
+
+// Record_Value is the numeric value of a record.
+type Record_Value struct {
+	Value float64
+}
+
+// Record_StringValue is the string value of a record.
+type Record_StringValue struct {
+	StringValue string
+}
+
+// Record_BoolValue is the boolean value of a record.
+type Record_BoolValue struct {
+	BoolValue bool
+}
+
+// Record_DataValue is the data value of a record.
+type Record_DataValue struct {
+	DataValue []byte
+}
+
+func (*Record_Value) isRecord_Kind()       {}
+func (*Record_StringValue) isRecord_Kind() {}
+func (*Record_BoolValue) isRecord_Kind()   {}
+func (*Record_DataValue) isRecord_Kind()   {}
+
+// GetBaseName returns the base name of the record.
+func (x *Record) GetBaseName() string {
+	if x != nil {
+		return x.BaseName
+	}
+	return ""
+}
+
+// GetBaseTime returns the base time of the record.
+func (x *Record) GetBaseTime() float64 {
+	if x != nil {
+		return x.BaseTime
+	}
+	return 0
+}
+
+// GetBaseUnit returns the base unit of the record.
+func (x *Record) GetBaseUnit() string {
+	if x != nil {
+		return x.BaseUnit
+	}
+	return ""
+}
+
+// GetBaseValue returns the base value of the record.
+func (x *Record) GetBaseValue() float64 {
+	if x != nil && x.BaseValue != nil {
+		return *x.BaseValue
+	}
+	return 0
+}
+
+// GetBaseSum returns the base sum of the record.
+func (x *Record) GetBaseSum() float64 {
+	if x != nil && x.BaseSum != nil {
+		return *x.BaseSum
+	}
+	return 0
+}
+
+// GetBaseVersion returns the base version of the record.
+func (x *Record) GetBaseVersion() int32 {
+	if x != nil {
+		return x.BaseVersion
+	}
+	return 0
+}
+
+// GetName returns the name of the record.
+func (x *Record) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// GetUnit returns the unit of the record.
+func (x *Record) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+// GetTime returns the time of the record.
+func (x *Record) GetTime() float64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+// GetUpdateTime returns the update time of the record.
+func (x *Record) GetUpdateTime() float64 {
+	if x != nil {
+		return x.UpdateTime
+	}
+	return 0
+}
+
+// GetKind returns the value oneof of the record.
+func (x *Record) GetKind() isRecord_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return nil
+}
+
+// GetValue returns the numeric value of the record, if set.
+func (x *Record) GetValue() float64 {
+	if v, ok := x.GetKind().(*Record_Value); ok {
+		return v.Value
+	}
+	return 0
+}
+
+// GetStringValue returns the string value of the record, if set.
+func (x *Record) GetStringValue() string {
+	if v, ok := x.GetKind().(*Record_StringValue); ok {
+		return v.StringValue
+	}
+	return ""
+}
+
+// GetBoolValue returns the boolean value of the record, if set.
+func (x *Record) GetBoolValue() bool {
+	if v, ok := x.GetKind().(*Record_BoolValue); ok {
+		return v.BoolValue
+	}
+	return false
+}
+
+// GetDataValue returns the data value of the record, if set.
+func (x *Record) GetDataValue() []byte {
+	if v, ok := x.GetKind().(*Record_DataValue); ok {
+		return v.DataValue
+	}
+	return nil
+}
+
+// GetSum returns the sum of the record.
+func (x *Record) GetSum() float64 {
+	if x != nil && x.Sum != nil {
+		return *x.Sum
+	}
+	return 0
+}
+
+// wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// Marshal encodes the pack in the Protocol Buffers wire format.
+func (x *Pack) Marshal() ([]byte, error) {
+	var b []byte
+	for _, r := range x.GetRecords() {
+		rb, err := r.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		b = appendBytes(b, 1, rb)
+	}
+	return b, nil
+}
+
+// Unmarshal decodes a pack encoded in the Protocol Buffers wire format.
+// Unknown fields are skipped.
+func (x *Pack) Unmarshal(b []byte) error {
+	x.Records = nil
+	return walk(b, func(num int, typ int, v uint64, data []byte) error {
+		if num != 1 {
+			return nil
+		}
+		if typ != wireBytes {
+			return ErrInvalidData
+		}
+		r := &Record{}
+		if err := r.Unmarshal(data); err != nil {
+			return err
+		}
+		x.Records = append(x.Records, r)
+		return nil
+	})
+}
+
+// Marshal encodes the record in the Protocol Buffers wire format.
+func (x *Record) Marshal() ([]byte, error) {
+	var b []byte
+	if x.BaseName != "" {
+		b = appendBytes(b, 1, []byte(x.BaseName))
+	}
+	if x.BaseTime != 0 {
+		b = appendDouble(b, 2, x.BaseTime)
+	}
+	if x.BaseUnit != "" {
+		b = appendBytes(b, 3, []byte(x.BaseUnit))
+	}
+	if x.BaseValue != nil {
+		b = appendDouble(b, 4, *x.BaseValue)
+	}
+	if x.BaseSum != nil {
+		b = appendDouble(b, 5, *x.BaseSum)
+	}
+	if x.BaseVersion != 0 {
+		// negative int32 values are sign extended to 64 bits
+		b = appendVarint(b, 6, uint64(int64(x.BaseVersion)))
+	}
+	if x.Name != "" {
+		b = appendBytes(b, 7, []byte(x.Name))
+	}
+	if x.Unit != "" {
+		b = appendBytes(b, 8, []byte(x.Unit))
+	}
+	if x.Time != 0 {
+		b = appendDouble(b, 9, x.Time)
+	}
+	if x.UpdateTime != 0 {
+		b = appendDouble(b, 10, x.UpdateTime)
+	}
+	switch v := x.Kind.(type) {
+	case nil:
+	case *Record_Value:
+		b = appendDouble(b, 11, v.Value)
+	case *Record_StringValue:
+		b = appendBytes(b, 12, []byte(v.StringValue))
+	case *Record_BoolValue:
+		var u uint64
+		if v.BoolValue {
+			u = 1
+		}
+		b = appendVarint(b, 13, u)
+	case *Record_DataValue:
+		b = appendBytes(b, 14, v.DataValue)
+	}
+	if x.Sum != nil {
+		b = appendDouble(b, 15, *x.Sum)
+	}
+	return b, nil
+}
+
+// Unmarshal decodes a record encoded in the Protocol Buffers wire format.
+// Unknown fields are skipped.
+func (x *Record) Unmarshal(b []byte) error {
+	*x = Record{}
+	return walk(b, func(num int, typ int, v uint64, data []byte) error {
+		expected := wireFixed64
+		switch num {
+		case 1, 3, 7, 8, 12, 14:
+			expected = wireBytes
+		case 6, 13:
+			expected = wireVarint
+		case 2, 4, 5, 9, 10, 11, 15:
+		default:
+			return nil
+		}
+		if typ != expected {
+			return ErrInvalidData
+		}
+		f := math.Float64frombits(v)
+		switch num {
+		case 1:
+			x.BaseName = string(data)
+		case 2:
+			x.BaseTime = f
+		case 3:
+			x.BaseUnit = string(data)
+		case 4:
+			x.BaseValue = &f
+		case 5:
+			x.BaseSum = &f
+		case 6:
+			x.BaseVersion = int32(v)
+		case 7:
+			x.Name = string(data)
+		case 8:
+			x.Unit = string(data)
+		case 9:
+			x.Time = f
+		case 10:
+			x.UpdateTime = f
+		case 11:
+			x.Kind = &Record_Value{Value: f}
+		case 12:
+			x.Kind = &Record_StringValue{StringValue: string(data)}
+		case 13:
+			x.Kind = &Record_BoolValue{BoolValue: v != 0}
+		case 14:
+			x.Kind = &Record_DataValue{DataValue: append([]byte{}, data...)}
+		case 15:
+			x.Sum = &f
+		}
+		return nil
+	})
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+func appendTag(b []byte, num int, typ int) []byte {
+	return appendUvarint(b, uint64(num)<<3|uint64(typ))
+}
+
+func appendVarint(b []byte, num int, v uint64) []byte {
+	return appendUvarint(appendTag(b, num, wireVarint), v)
+}
+
+func appendDouble(b []byte, num int, f float64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], math.Float64bits(f))
+	return append(appendTag(b, num, wireFixed64), buf[:]...)
+}
+
+func appendBytes(b []byte, num int, data []byte) []byte {
+	b = appendUvarint(appendTag(b, num, wireBytes), uint64(len(data)))
+	return append(b, data...)
+}
+
+// walk calls fn for each field of a message.
+// v holds the value of varint and fixed fields, and data the content of length-delimited fields.
+func walk(b []byte, fn func(num int, typ int, v uint64, data []byte) error) error {
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 || tag>>3 == 0 || tag>>3 > math.MaxInt32 {
+			return ErrInvalidData
+		}
+		b = b[n:]
+		num, typ := int(tag>>3), int(tag&7)
+		var v uint64
+		var data []byte
+		switch typ {
+		case wireVarint:
+			if v, n = binary.Uvarint(b); n <= 0 {
+				return ErrInvalidData
+			}
+			b = b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				return ErrInvalidData
+			}
+			v, b = binary.LittleEndian.Uint64(b), b[8:]
+		case wireBytes:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || l > uint64(len(b)-n) {
+				return ErrInvalidData
+			}
+			data, b = b[n:n+int(l)], b[n+int(l):]
+		case wireFixed32:
+			if len(b) < 4 {
+				return ErrInvalidData
+			}
+			v, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		default:
+			// groups are not supported
+			return ErrInvalidData
+		}
+		if err := fn(num, typ, v, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package senmlpb
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestWireFormat(t *testing.T) {
+	tcs := []struct {
+		src *Pack
+		enc []byte
+	}{
+		{
+			src: &Pack{},
+			enc: nil,
+		},
+		{
+			src: &Pack{Records: []*Record{{Name: "a", Kind: &Record_Value{Value: 1}}}},
+			enc: []byte{0x0a, 0x0c, 0x3a, 0x01, 'a', 0x59, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f},
+		},
+		{
+			src: &Pack{Records: []*Record{{BaseVersion: 10, Kind: &Record_BoolValue{BoolValue: true}}, {Kind: &Record_DataValue{DataValue: []byte{}}}}},
+			enc: []byte{0x0a, 0x04, 0x30, 0x0a, 0x68, 0x01, 0x0a, 0x02, 0x72, 0x00},
+		},
+	}
+	for _, tc := range tcs {
+		enc, err := tc.src.Marshal()
+		if err != nil {
+			t.Errorf("encoding of %+v returned an error : %s", tc.src, err)
+		}
+		if !bytes.Equal(enc, tc.enc) {
+			t.Errorf("encoding of %+v should be %x not %x", tc.src, tc.enc, enc)
+		}
+		dec := &Pack{}
+		if err := dec.Unmarshal(tc.enc); err != nil {
+			t.Errorf("decoding of %x returned an error : %s", tc.enc, err)
+		}
+		if len(dec.Records) != len(tc.src.Records) || (len(dec.Records) > 0 && !reflect.DeepEqual(dec, tc.src)) {
+			t.Errorf("decoding of %x should be %+v not %+v", tc.enc, tc.src, dec)
+		}
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	// unknown fields of all wire types are skipped, and the last value of a oneof wins
+	enc := []byte{
+		0x0a, 0x1c,
+		0x80, 0x01, 0x05, // field 16, varint
+		0x89, 0x01, 1, 2, 3, 4, 5, 6, 7, 8, // field 17, fixed64
+		0x92, 0x01, 0x01, 'x', // field 18, bytes
+		0x9d, 0x01, 1, 2, 3, 4, // field 19, fixed32
+		0x62, 0x01, 'a', // string value
+		0x68, 0x01, // bool value
+		0x10, 0x01, // field 2 of the pack
+	}
+	dec := &Pack{}
+	if err := dec.Unmarshal(enc); err != nil {
+		t.Fatalf("decoding of %x returned an error : %s", enc, err)
+	}
+	if exp := (&Pack{Records: []*Record{{Kind: &Record_BoolValue{BoolValue: true}}}}); !reflect.DeepEqual(dec, exp) {
+		t.Errorf("decoding of %x should be %+v not %+v", enc, exp, dec)
+	}
+
+	for _, enc := range [][]byte{
+		{0x0a},
+		{0x0a, 0x02, 0x3a},
+		{0x08, 0x01},
+		{0x0a, 0x02, 0x3a, 0x01},
+		{0x0a, 0x02, 0x48, 0x01},
+		{0x0a, 0x01, 0x59},
+		{0x0a, 0x02, 0x6b, 0x6c},
+		{0x00},
+	} {
+		if err := (&Pack{}).Unmarshal(enc); err != ErrInvalidData {
+			t.Errorf("decoding of %x should return ErrInvalidData, not %v", enc, err)
+		}
+	}
+}
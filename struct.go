@@ -0,0 +1,300 @@
+package senml
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ErrNotStruct is returned by MarshalStruct and UnmarshalStruct when v is not a struct, or a pointer to a struct.
+var ErrNotStruct = errors.New("senml: not a struct")
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// structField is a struct field with a senml tag.
+type structField struct {
+	index []int
+	name  string
+	unit  Unit
+	sum   bool
+}
+
+// structFields returns the fields of t with a senml tag, and the index of its time.Time field, if any.
+//
+// The tag is the name of the record, relative to the base name, followed by options :
+// "unit=..." sets the unit of the record, and "sum" stores numeric values as sums.
+// If the name is empty, the name of the field is used. The time.Time field has no name nor options.
+func structFields(t reflect.Type) ([]structField, []int, error) {
+	var fields []structField
+	var timeIndex []int
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, found := f.Tag.Lookup("senml")
+		if !found || tag == "-" || f.PkgPath != "" {
+			continue
+		}
+		if f.Type == timeType {
+			if timeIndex != nil {
+				return nil, nil, fmt.Errorf("senml: several time fields in %s", t)
+			}
+			timeIndex = f.Index
+			continue
+		}
+		if !supportedType(f.Type) {
+			return nil, nil, fmt.Errorf("senml: unsupported type %s for field %s", f.Type, f.Name)
+		}
+		opts := strings.Split(tag, ",")
+		sf := structField{index: f.Index, name: opts[0]}
+		if sf.name == "" {
+			sf.name = f.Name
+		}
+		for _, opt := range opts[1:] {
+			switch {
+			case strings.HasPrefix(opt, "unit="):
+				sf.unit = Unit(strings.TrimPrefix(opt, "unit="))
+			case opt == "sum":
+				sf.sum = true
+			default:
+				return nil, nil, fmt.Errorf("senml: unknown option %q for field %s", opt, f.Name)
+			}
+		}
+		if sf.sum && !isNumeric(f.Type) {
+			return nil, nil, fmt.Errorf("senml: sum option for non numeric field %s", f.Name)
+		}
+		fields = append(fields, sf)
+	}
+	return fields, timeIndex, nil
+}
+
+func supportedType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	}
+	return isNumeric(t)
+}
+
+func isNumeric(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func structValue(v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return rv, ErrNotStruct
+	}
+	return rv, nil
+}
+
+// MarshalStruct converts the fields of a struct with a senml tag to a pack, e.g. :
+//
+//	type Telemetry struct {
+//		Time        time.Time `senml:""`
+//		Temperature float64   `senml:"temp,unit=Cel"`
+//		Energy      int       `senml:"energy,unit=J,sum"`
+//		Open        *bool     `senml:"open"`
+//		Label       string    `senml:"label"`
+//		Raw         []byte    `senml:"raw"`
+//	}
+//
+// Numeric fields are mapped to values (or sums with the "sum" option), and bool, string and []byte fields
+// to boolean, string and data values. Nil pointers, empty strings and nil slices are omitted.
+// The time.Time field, if any and not zero, is the base time of the pack.
+func MarshalStruct(v interface{}, baseName string) (Pack, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+	fields, timeIndex, err := structFields(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+	p := Pack{}
+	for _, f := range fields {
+		fv := rv.FieldByIndex(f.index)
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+		r := Record{Name: f.name, Unit: f.unit}
+		switch fv.Kind() {
+		case reflect.Bool:
+			r.BoolValue = Bool(fv.Bool())
+		case reflect.String:
+			if fv.Len() == 0 {
+				continue
+			}
+			r.StringValue = fv.String()
+		case reflect.Slice:
+			if fv.IsNil() {
+				continue
+			}
+			r.DataValue = fv.Bytes()
+		default:
+			var f64 float64
+			switch fv.Kind() {
+			case reflect.Float32, reflect.Float64:
+				f64 = fv.Float()
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				f64 = float64(fv.Int())
+			default:
+				f64 = float64(fv.Uint())
+			}
+			if f.sum {
+				r.Sum = Float(f64)
+			} else {
+				r.Value = Float(f64)
+			}
+		}
+		p = append(p, r)
+	}
+	if len(p) > 0 {
+		p[0].BaseName = baseName
+		if timeIndex != nil {
+			if t := rv.FieldByIndex(timeIndex).Interface().(time.Time); !t.IsZero() {
+				p[0].BaseTime = Time(t)
+			}
+		}
+	}
+	return p, nil
+}
+
+// UnmarshalStruct sets the fields of the struct pointed to by v with a senml tag, from the values of a pack.
+//
+// A field matches the records whose resolved name is the name in its tag, either alone
+// or prefixed by one of the base names of the pack. If several records match, the latest one is used.
+// Fields without matching records are left unchanged, and the time.Time field, if any, is set
+// to the time of the latest matching record, unless it has no time.
+// An error is returned if the kind of value or the unit of a record does not match its field.
+func UnmarshalStruct(p Pack, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return ErrNotStruct
+	}
+	rv, err := structValue(v)
+	if err != nil {
+		return err
+	}
+	fields, timeIndex, err := structFields(rv.Type())
+	if err != nil {
+		return err
+	}
+	baseNames := []string{""}
+	for _, r := range p {
+		if r.BaseName != "" {
+			baseNames = append(baseNames, r.BaseName)
+		}
+	}
+	var latest float64
+	found := false
+	n := p.Normalize()
+	for _, f := range fields {
+		var rec *Record
+		for i := range n {
+			for _, bn := range baseNames {
+				if n[i].Name == bn+f.name {
+					// records are sorted chronologically
+					rec = &n[i]
+					break
+				}
+			}
+		}
+		if rec == nil {
+			continue
+		}
+		if f.unit != "" && rec.Unit != "" && rec.Unit != f.unit {
+			return fmt.Errorf("senml: unit of %s should be %s, not %s", f.name, f.unit, rec.Unit)
+		}
+		if err := setField(rv.FieldByIndex(f.index), rec, f); err != nil {
+			return err
+		}
+		if !found || rec.Time > latest {
+			latest = rec.Time
+		}
+		found = true
+	}
+	if found && latest != 0 && timeIndex != nil {
+		rv.FieldByIndex(timeIndex).Set(reflect.ValueOf(GoTime(latest)))
+	}
+	return nil
+}
+
+func setField(fv reflect.Value, r *Record, f structField) error {
+	if fv.Kind() == reflect.Ptr {
+		nv := reflect.New(fv.Type().Elem())
+		if err := setField(nv.Elem(), r, f); err != nil {
+			return err
+		}
+		fv.Set(nv)
+		return nil
+	}
+	mismatch := func() error {
+		return fmt.Errorf("senml: record %s does not hold a value of type %s", r.Name, fv.Type())
+	}
+	switch fv.Kind() {
+	case reflect.Bool:
+		if r.BoolValue == nil {
+			return mismatch()
+		}
+		fv.SetBool(*r.BoolValue)
+	case reflect.String:
+		if r.StringValue == "" {
+			return mismatch()
+		}
+		fv.SetString(r.StringValue)
+	case reflect.Slice:
+		if r.DataValue == nil {
+			return mismatch()
+		}
+		fv.SetBytes(r.DataValue)
+	default:
+		val := r.Value
+		if f.sum {
+			val = r.Sum
+		}
+		if val == nil {
+			return mismatch()
+		}
+		f64 := *val
+		switch fv.Kind() {
+		case reflect.Float32, reflect.Float64:
+			if fv.OverflowFloat(f64) {
+				return mismatch()
+			}
+			fv.SetFloat(f64)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if f64 != math.Trunc(f64) || f64 < math.MinInt64 || f64 >= math.MaxInt64 || fv.OverflowInt(int64(f64)) {
+				return mismatch()
+			}
+			fv.SetInt(int64(f64))
+		default:
+			if f64 != math.Trunc(f64) || f64 < 0 || f64 >= math.MaxUint64 || fv.OverflowUint(uint64(f64)) {
+				return mismatch()
+			}
+			fv.SetUint(uint64(f64))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package senml
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type telemetry struct {
+	Time        time.Time `senml:""`
+	Temperature float64   `senml:"temp,unit=Cel"`
+	Energy      uint32    `senml:"energy,unit=J,sum"`
+	Level       int8      `senml:",unit=%"`
+	Open        *bool     `senml:"open"`
+	Label       string    `senml:"label"`
+	Raw         []byte    `senml:"raw"`
+	Ignored     int       `senml:"-"`
+	Untagged    int
+	unexported  int `senml:"unexported"`
+}
+
+func TestMarshalStruct(t *testing.T) {
+	now := time.Unix(1320067464, 0)
+	src := telemetry{Time: now, Temperature: 23.1, Energy: 1200, Level: -5, Open: Bool(true), Raw: []byte{0xca, 0xfe}, Ignored: 1, Untagged: 2, unexported: 3}
+	exp := Pack{
+		{BaseName: "urn:dev:ow:10e2073a01080063:", BaseTime: 1.320067464e+09, Name: "temp", Unit: Celsius, Value: Float(23.1)},
+		{Name: "energy", Unit: Joule, Sum: Float(1200)},
+		{Name: "Level", Unit: Percentage, Value: Float(-5)},
+		{Name: "open", BoolValue: True},
+		{Name: "raw", DataValue: []byte{0xca, 0xfe}},
+	}
+	for _, v := range []interface{}{src, &src} {
+		p, err := MarshalStruct(v, "urn:dev:ow:10e2073a01080063:")
+		if err != nil {
+			t.Fatalf("encoding of %+v returned an error : %s", v, err)
+		}
+		if !reflect.DeepEqual(p, exp) {
+			t.Errorf("encoding of %+v should be %+v not %+v", v, exp, p)
+		}
+	}
+
+	var dec telemetry
+	if err := UnmarshalStruct(exp, &dec); err != nil {
+		t.Fatalf("decoding of %+v returned an error : %s", exp, err)
+	}
+	src.Ignored, src.Untagged, src.unexported = 0, 0, 0
+	if !reflect.DeepEqual(dec, src) {
+		t.Errorf("decoding of %+v should be %+v not %+v", exp, src, dec)
+	}
+
+	// the latest record is used, with or without base name
+	p := Pack{
+		{Name: "temp", Time: 1.320067464e+09, Value: Float(20)},
+		{BaseName: "foo:", Name: "temp", Time: 1.320067524e+09, Value: Float(21)},
+		{Name: "label", StringValue: "kitchen"},
+	}
+	dec = telemetry{Level: 1}
+	if err := UnmarshalStruct(p, &dec); err != nil {
+		t.Fatalf("decoding of %+v returned an error : %s", p, err)
+	}
+	if exp := (telemetry{Time: time.Unix(1320067524, 0), Temperature: 21, Level: 1, Label: "kitchen"}); !reflect.DeepEqual(dec, exp) {
+		t.Errorf("decoding of %+v should be %+v not %+v", p, exp, dec)
+	}
+}
+
+func TestStructErrors(t *testing.T) {
+	var dec telemetry
+	if _, err := MarshalStruct(1, ""); err != ErrNotStruct {
+		t.Errorf("encoding of 1 should return ErrNotStruct, not %v", err)
+	}
+	i := 1
+	for _, v := range []interface{}{nil, 1, &i, dec} {
+		if err := UnmarshalStruct(nil, v); err != ErrNotStruct {
+			t.Errorf("decoding to %+v should return ErrNotStruct, not %v", v, err)
+		}
+	}
+	for _, v := range []interface{}{
+		&struct {
+			A, B time.Time `senml:""`
+		}{},
+		&struct {
+			A []int `senml:"a"`
+		}{},
+		&struct {
+			A int `senml:"a,foo"`
+		}{},
+		&struct {
+			A bool `senml:"a,sum"`
+		}{},
+	} {
+		if _, err := MarshalStruct(v, ""); err == nil {
+			t.Errorf("encoding of %+v should return an error", v)
+		}
+		if err := UnmarshalStruct(nil, v); err == nil {
+			t.Errorf("decoding to %+v should return an error", v)
+		}
+	}
+	for _, p := range []Pack{
+		{{Name: "temp", Unit: Kelvin, Value: Float(300)}},
+		{{Name: "temp", StringValue: "foo"}},
+		{{Name: "energy", Value: Float(1)}},
+		{{Name: "energy", Sum: Float(-1)}},
+		{{Name: "Level", Value: Float(1.5)}},
+		{{Name: "Level", Value: Float(128)}},
+		{{Name: "open", Value: Float(1)}},
+		{{Name: "label", BoolValue: True}},
+		{{Name: "raw", StringValue: "foo"}},
+	} {
+		if err := UnmarshalStruct(p, &dec); err == nil {
+			t.Errorf("decoding of %+v should return an error", p)
+		}
+	}
+	if dec.Open != nil {
+		t.Errorf("decoding errors should not set pointer fields")
+	}
+}
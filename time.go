@@ -17,10 +17,12 @@ func GoTime(f float64) time.Time {
 	return time.Unix(int64(sec), int64(nsec))
 }
 
-var maxRelativeTime = math.Pow(2, 28)
+// DefaultMaxRelativeTime is the largest relative time (2**28 seconds, about 8.5 years), as defined by RFC 8428 :
+// larger times are absolute.
+const DefaultMaxRelativeTime = 1 << 28
 
-func absoluteTime(t float64, ref float64) float64 {
-	if t <= maxRelativeTime {
+func absoluteTime(t float64, ref float64, max float64) float64 {
+	if t <= max {
 		return t + ref
 	}
 	return t
@@ -0,0 +1,49 @@
+package senml
+
+import "strconv"
+
+const (
+	// Version is the latest SenML version supported by the package (RFC 8428).
+	// Packs with a higher base version are rejected by decoding.
+	Version = 10
+	// DefaultVersion is the version of packs without base version.
+	DefaultVersion = 10
+)
+
+// VersionError is returned when decoding a pack with a base version higher than Version.
+type VersionError struct {
+	// Record is the index of the record in its pack, or -1 if the record is not part of a pack.
+	Record int
+	// Version is the base version of the record.
+	Version int
+}
+
+func (e *VersionError) Error() string {
+	s := "senml: "
+	if e.Record >= 0 {
+		s += "record " + strconv.Itoa(e.Record) + ": "
+	}
+	return s + "unsupported version " + strconv.Itoa(e.Version) + ", the supported version is " + strconv.Itoa(Version)
+}
+
+// checkVersion returns a *VersionError if the base version of the i-th record is not supported.
+func (r *Record) checkVersion(i int) error {
+	if r.BaseVersion > Version {
+		return &VersionError{Record: i, Version: r.BaseVersion}
+	}
+	return nil
+}
+
+// withoutVersions returns a copy of the pack, with the base version of records removed :
+// all the labels of Record are defined by RFC 8428, so packs never need more than the default version.
+func (p Pack) withoutVersions() Pack {
+	if p == nil {
+		return nil
+	}
+	n := make(Pack, len(p))
+	copy(n, p)
+	for i := range n {
+		n[i].BaseVersion = 0
+	}
+	return n
+}
@@ -0,0 +1,56 @@
+package senml
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+)
+
+func TestDecodeVersion(t *testing.T) {
+	var p Pack
+	if err := json.Unmarshal([]byte(`[{"bn":"dev:","bver":10,"n":"a","v":1},{"bver":9,"n":"b","v":2}]`), &p); err != nil {
+		t.Errorf("JSON decoding of supported versions returned an error : %s", err)
+	}
+	err := json.Unmarshal([]byte(`[{"bn":"dev:","n":"a","v":1},{"bver":11,"n":"b","v":2}]`), &p)
+	if ve, ok := err.(*VersionError); !ok || ve.Record != 1 || ve.Version != 11 {
+		t.Errorf("JSON decoding of an unsupported version should return a *VersionError for record 1, not %v", err)
+	}
+	var r Record
+	if err := json.Unmarshal([]byte(`{"bver":11,"n":"b","v":2}`), &r); err == nil {
+		t.Error("JSON decoding of a record with an unsupported version should return an error")
+	}
+
+	src := `<sensml xmlns="urn:ietf:params:xml:ns:senml"><senml bver="11" n="a" v="1"></senml></sensml>`
+	err = xml.Unmarshal([]byte(src), &p)
+	if ve, ok := err.(*VersionError); !ok || ve.Record != 0 || ve.Version != 11 {
+		t.Errorf("XML decoding of %s should return a *VersionError for record 0, not %v", src, err)
+	}
+	if _, err := DecodeXML([]byte(src), XMLOptions{Lenient: true}); err == nil {
+		t.Errorf("lenient XML decoding of %s should return an error", src)
+	}
+}
+
+func TestAutoVersion(t *testing.T) {
+	p := Pack{
+		{BaseName: "dev:", BaseVersion: 5, Name: "temp", Value: Float(21)},
+		{BaseVersion: 10, Name: "open", BoolValue: True},
+	}
+	enc, err := EncodeJSON(p, JSONOptions{AutoVersion: true})
+	if exp := `[{"bn":"dev:","n":"temp","v":21},{"n":"open","vb":true}]`; err != nil || string(enc) != exp {
+		t.Errorf("JSON encoding of %+v should be %s not %s (%v)", p, exp, enc, err)
+	}
+	enc, err = EncodeXML(p, XMLOptions{AutoVersion: true})
+	if exp := `<sensml xmlns="urn:ietf:params:xml:ns:senml"><senml bn="dev:" n="temp" v="21"></senml><senml n="open" vb="true"></senml></sensml>`; err != nil || string(enc) != exp {
+		t.Errorf("XML encoding of %+v should be %s not %s (%v)", p, exp, enc, err)
+	}
+	if p[0].BaseVersion != 5 || p[1].BaseVersion != 10 {
+		t.Errorf("encoding with AutoVersion should not modify the pack")
+	}
+	enc, err = EncodeJSON(p, JSONOptions{})
+	if exp := `[{"bn":"dev:","bver":5,"n":"temp","v":21},{"bver":10,"n":"open","vb":true}]`; err != nil || string(enc) != exp {
+		t.Errorf("JSON encoding of %+v should be %s not %s (%v)", p, exp, enc, err)
+	}
+	if enc, err := EncodeJSON(nil, JSONOptions{AutoVersion: true}); err != nil || string(enc) != "null" {
+		t.Errorf("JSON encoding of a nil pack should be null not %s (%v)", enc, err)
+	}
+}
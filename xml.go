@@ -0,0 +1,367 @@
+package senml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// XMLNamespace is the namespace of SenML XML documents.
+const XMLNamespace = "urn:ietf:params:xml:ns:senml"
+
+// XMLOptions define how XML documents are encoded and decoded.
+type XMLOptions struct {
+	// Lenient accepts documents from legacy producers : any root element and namespace,
+	// elements other than senml records are ignored, and attribute values are parsed like encoding/xml does
+	// (surrounding spaces are trimmed, numbers may be "0x1p4" or "infinity", and booleans may be "t", "1", ...).
+	//
+	// In both modes, unknown attributes are ignored, except the ones ending with "_" which must be understood,
+	// as defined in https://tools.ietf.org/html/rfc8428#section-4.4.
+	Lenient bool
+	// NonFinite is the policy for non-finite numbers. When decoding, NullNonFinite discards non-finite values,
+	// and StringNonFinite accepts them.
+	NonFinite NonFinitePolicy
+	// AutoVersion removes the base versions of the records when encoding, see JSONOptions.
+	AutoVersion bool
+}
+
+// XMLError is returned when decoding an invalid XML document.
+type XMLError struct {
+	// Record is the index of the invalid record, or -1 if the error is not related to a record.
+	Record int
+	// Attr is the name of the invalid attribute, if any.
+	Attr string
+	// Msg describes the error.
+	Msg string
+}
+
+func (e *XMLError) Error() string {
+	s := "senml: invalid XML: "
+	if e.Record >= 0 {
+		s += "record " + strconv.Itoa(e.Record) + ": "
+	}
+	if e.Attr != "" {
+		s += "attribute " + e.Attr + ": "
+	}
+	return s + e.Msg
+}
+
+// EncodeXML encodes a pack to XML. xml.Marshal(p) is equivalent to EncodeXML(p, XMLOptions{}).
+func EncodeXML(p Pack, opts XMLOptions) ([]byte, error) {
+	if opts.AutoVersion {
+		p = p.withoutVersions()
+	}
+	n, err := p.xmlPack(opts.NonFinite)
+	if err != nil {
+		return nil, err
+	}
+	return xml.Marshal(n)
+}
+
+// xmlPack converts the pack to its XML representation.
+func (p Pack) xmlPack(nonFinite NonFinitePolicy) (xmlPack, error) {
+	n := xmlPack{
+		Xmlns:   XMLNamespace,
+		Records: make([]xmlRecord, 0, len(p)),
+	}
+	for i := range p {
+		keep, err := p[i].checkFinite(i, nonFinite)
+		if err != nil {
+			return n, err
+		}
+		if !keep {
+			continue
+		}
+		n.Records = append(n.Records, xmlRecord{
+			Record:    p[i],
+			BaseValue: formatXMLFloat(p[i].BaseValue, nonFinite),
+			BaseSum:   formatXMLFloat(p[i].BaseSum, nonFinite),
+			Value:     formatXMLFloat(p[i].Value, nonFinite),
+			DataValue: EncodeDataValue(p[i].DataValue),
+			Sum:       formatXMLFloat(p[i].Sum, nonFinite),
+		})
+	}
+	return n, nil
+}
+
+// formatXMLFloat formats a value like encoding/xml does, and non-finite values as xs:double.
+func formatXMLFloat(f *float64, nonFinite NonFinitePolicy) string {
+	switch {
+	case f == nil:
+		return ""
+	case isFinite(*f):
+		return strconv.FormatFloat(*f, 'g', -1, 64)
+	case nonFinite != StringNonFinite:
+		return ""
+	case math.IsNaN(*f):
+		return "NaN"
+	case *f > 0:
+		return "INF"
+	}
+	return "-INF"
+}
+
+// DecodeXML decodes a XML encoded SenML Pack.
+// xml.Unmarshal(data, &p) is equivalent to DecodeXML(data, XMLOptions{}).
+func DecodeXML(data []byte, opts XMLOptions) (Pack, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil, &XMLError{Record: -1, Msg: "no root element"}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			p := Pack{}
+			if err := p.decodeXML(d, start, opts); err != nil {
+				return nil, err
+			}
+			return p, nil
+		}
+	}
+}
+
+// decodeXML decodes the content of the root element.
+func (p *Pack) decodeXML(d *xml.Decoder, start xml.StartElement, opts XMLOptions) error {
+	if !opts.Lenient && (start.Name.Local != "sensml" || start.Name.Space != XMLNamespace) {
+		return &XMLError{Record: -1, Msg: fmt.Sprintf("root element should be sensml in namespace %s, not %s", XMLNamespace, formatXMLName(start.Name))}
+	}
+	n := Pack{}
+	index := 0
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch tok := tok.(type) {
+		case xml.EndElement:
+			*p = n
+			return nil
+		case xml.CharData:
+			if !opts.Lenient && len(bytes.TrimSpace(tok)) > 0 {
+				return &XMLError{Record: -1, Msg: fmt.Sprintf("unexpected text %q", string(tok))}
+			}
+		case xml.StartElement:
+			if tok.Name.Local != "senml" || (!opts.Lenient && tok.Name.Space != XMLNamespace) {
+				if !opts.Lenient {
+					return &XMLError{Record: -1, Msg: fmt.Sprintf("child elements should be senml in namespace %s, not %s", XMLNamespace, formatXMLName(tok.Name))}
+				}
+				if err := d.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			r, err := decodeXMLRecord(d, tok, index, opts)
+			if err != nil {
+				return err
+			}
+			if err := r.checkVersion(index); err != nil {
+				return err
+			}
+			keep, err := r.checkFinite(index, opts.NonFinite)
+			if err != nil {
+				return err
+			}
+			index++
+			if !keep {
+				continue
+			}
+			if opts.NonFinite == NullNonFinite {
+				r.discardNonFinite()
+			}
+			n = append(n, r)
+		}
+	}
+}
+
+// decodeXMLRecord decodes a senml element.
+func decodeXMLRecord(d *xml.Decoder, start xml.StartElement, index int, opts XMLOptions) (Record, error) {
+	var r Record
+	seen := map[string]bool{}
+	for _, attr := range start.Attr {
+		if attr.Name.Space != "" || attr.Name.Local == "xmlns" {
+			// namespace declarations and attributes of other namespaces
+			continue
+		}
+		name := attr.Name.Local
+		if seen[name] {
+			return r, &XMLError{Record: index, Attr: name, Msg: "duplicate attribute"}
+		}
+		seen[name] = true
+		if err := r.setXMLAttr(name, attr.Value, opts); err != nil {
+			return r, &XMLError{Record: index, Attr: name, Msg: err.Error()}
+		}
+	}
+	if opts.Lenient {
+		return r, d.Skip()
+	}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return r, err
+		}
+		switch tok := tok.(type) {
+		case xml.EndElement:
+			return r, nil
+		case xml.CharData:
+			if len(bytes.TrimSpace(tok)) > 0 {
+				return r, &XMLError{Record: index, Msg: fmt.Sprintf("unexpected text %q", string(tok))}
+			}
+		case xml.StartElement:
+			return r, &XMLError{Record: index, Msg: fmt.Sprintf("unexpected element %s", formatXMLName(tok.Name))}
+		}
+	}
+}
+
+// setXMLAttr sets the field of the record matching a XML attribute.
+func (r *Record) setXMLAttr(name, value string, opts XMLOptions) error {
+	var err error
+	switch name {
+	case "bn":
+		r.BaseName = value
+	case "bt":
+		r.BaseTime, err = parseXMLFloat(value, opts)
+	case "bu":
+		r.BaseUnit = Unit(value)
+	case "bv":
+		r.BaseValue, err = parseXMLFloatPtr(value, opts)
+	case "bs":
+		r.BaseSum, err = parseXMLFloatPtr(value, opts)
+	case "bver":
+		if opts.Lenient {
+			value = strings.TrimSpace(value)
+		}
+		if r.BaseVersion, err = strconv.Atoi(value); err != nil {
+			err = fmt.Errorf("invalid integer %q", value)
+		}
+	case "n":
+		r.Name = value
+	case "u":
+		r.Unit = Unit(value)
+	case "t":
+		r.Time, err = parseXMLFloat(value, opts)
+	case "ut":
+		r.UpdateTime, err = parseXMLFloat(value, opts)
+	case "v":
+		r.Value, err = parseXMLFloatPtr(value, opts)
+	case "vs":
+		r.StringValue = value
+	case "vd":
+		if opts.Lenient {
+			value = strings.TrimSpace(value)
+		}
+		if r.DataValue, err = DecodeDataValue(value); err != nil {
+			err = fmt.Errorf("invalid base64url data %q", value)
+		}
+	case "vb":
+		var b bool
+		if opts.Lenient {
+			b, err = strconv.ParseBool(strings.TrimSpace(value))
+		} else {
+			// xs:boolean
+			switch value {
+			case "true", "1":
+				b = true
+			case "false", "0":
+			default:
+				err = strconv.ErrSyntax
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		r.BoolValue = &b
+	case "s":
+		r.Sum, err = parseXMLFloatPtr(value, opts)
+	default:
+		// labels ending with "_" must be understood, see https://tools.ietf.org/html/rfc8428#section-4.4
+		if strings.HasSuffix(name, "_") {
+			return fmt.Errorf("unsupported must-understand attribute")
+		}
+	}
+	return err
+}
+
+// discardNonFinite sets the non-finite values to nil.
+func (r *Record) discardNonFinite() {
+	for _, f := range []**float64{&r.BaseValue, &r.BaseSum, &r.Value, &r.Sum} {
+		if *f != nil && !isFinite(**f) {
+			*f = nil
+		}
+	}
+}
+
+func parseXMLFloat(value string, opts XMLOptions) (float64, error) {
+	s := value
+	if opts.Lenient {
+		s = strings.TrimSpace(s)
+	}
+	if !opts.Lenient && !isXSDouble(s) {
+		return 0, fmt.Errorf("invalid number %q", value)
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", value)
+	}
+	return f, nil
+}
+
+// isXSDouble checks if s is a xs:double lexical value, i.e. a decimal number with an optional exponent, "INF", "+INF", "-INF" or "NaN".
+func isXSDouble(s string) bool {
+	if s == "NaN" {
+		return true
+	}
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		s = s[1:]
+	}
+	if s == "INF" {
+		return true
+	}
+	digits := func() int {
+		n := 0
+		for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+			n++
+		}
+		s = s[n:]
+		return n
+	}
+	n := digits()
+	if s != "" && s[0] == '.' {
+		s = s[1:]
+		n += digits()
+	}
+	if n == 0 {
+		return false
+	}
+	if s != "" && (s[0] == 'e' || s[0] == 'E') {
+		s = s[1:]
+		if s != "" && (s[0] == '+' || s[0] == '-') {
+			s = s[1:]
+		}
+		if digits() == 0 {
+			return false
+		}
+	}
+	return s == ""
+}
+
+func parseXMLFloatPtr(value string, opts XMLOptions) (*float64, error) {
+	f, err := parseXMLFloat(value, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func formatXMLName(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Local + " in namespace " + n.Space
+}
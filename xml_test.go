@@ -0,0 +1,131 @@
+package senml
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func TestXMLStrict(t *testing.T) {
+	tcs := []struct {
+		xml string
+		err string
+	}{
+		{
+			xml: `<foo xmlns="urn:ietf:params:xml:ns:senml"></foo>`,
+			err: "senml: invalid XML: root element should be sensml in namespace urn:ietf:params:xml:ns:senml, not foo in namespace urn:ietf:params:xml:ns:senml",
+		},
+		{
+			xml: `<sensml><senml n="foo" v="1"></senml></sensml>`,
+			err: "senml: invalid XML: root element should be sensml in namespace urn:ietf:params:xml:ns:senml, not sensml",
+		},
+		{
+			xml: `<sensml xmlns="urn:foo"></sensml>`,
+			err: "senml: invalid XML: root element should be sensml in namespace urn:ietf:params:xml:ns:senml, not sensml in namespace urn:foo",
+		},
+		{
+			xml: `<sensml xmlns="urn:ietf:params:xml:ns:senml"><record n="foo"/></sensml>`,
+			err: "senml: invalid XML: child elements should be senml in namespace urn:ietf:params:xml:ns:senml, not record in namespace urn:ietf:params:xml:ns:senml",
+		},
+		{
+			xml: `<sensml xmlns="urn:ietf:params:xml:ns:senml">foo<senml n="foo"/></sensml>`,
+			err: `senml: invalid XML: unexpected text "foo"`,
+		},
+		{
+			xml: `<sensml xmlns="urn:ietf:params:xml:ns:senml"><senml n="foo"/><senml n="bar"><foo/></senml></sensml>`,
+			err: "senml: invalid XML: record 1: unexpected element foo in namespace urn:ietf:params:xml:ns:senml",
+		},
+		{
+			xml: `<sensml xmlns="urn:ietf:params:xml:ns:senml"><senml n="foo">bar</senml></sensml>`,
+			err: `senml: invalid XML: record 0: unexpected text "bar"`,
+		},
+		{
+			xml: `<sensml xmlns="urn:ietf:params:xml:ns:senml"><senml n="foo" v=" 1"/></sensml>`,
+			err: `senml: invalid XML: record 0: attribute v: invalid number " 1"`,
+		},
+		{
+			xml: `<sensml xmlns="urn:ietf:params:xml:ns:senml"><senml n="foo" bt="abc"/></sensml>`,
+			err: `senml: invalid XML: record 0: attribute bt: invalid number "abc"`,
+		},
+		{
+			xml: `<sensml xmlns="urn:ietf:params:xml:ns:senml"><senml n="foo" bver="1.0"/></sensml>`,
+			err: `senml: invalid XML: record 0: attribute bver: invalid integer "1.0"`,
+		},
+		{
+			xml: `<sensml xmlns="urn:ietf:params:xml:ns:senml"><senml n="foo" vb="T"/></sensml>`,
+			err: `senml: invalid XML: record 0: attribute vb: invalid boolean "T"`,
+		},
+		{
+			xml: `<sensml xmlns="urn:ietf:params:xml:ns:senml"><senml n="foo" foo_="bar"/></sensml>`,
+			err: `senml: invalid XML: record 0: attribute foo_: unsupported must-understand attribute`,
+		},
+		{
+			xml: `<sensml xmlns="urn:ietf:params:xml:ns:senml"><senml n="foo" v="0x1p4"/></sensml>`,
+			err: `senml: invalid XML: record 0: attribute v: invalid number "0x1p4"`,
+		},
+		{
+			xml: `<sensml xmlns="urn:ietf:params:xml:ns:senml"><senml n="foo" v="infinity"/></sensml>`,
+			err: `senml: invalid XML: record 0: attribute v: invalid number "infinity"`,
+		},
+		{
+			xml: `<sensml xmlns="urn:ietf:params:xml:ns:senml"><senml n="foo" v="+Inf"/></sensml>`,
+			err: `senml: invalid XML: record 0: attribute v: invalid number "+Inf"`,
+		},
+		{
+			xml: `<sensml xmlns="urn:ietf:params:xml:ns:senml"><senml n="foo" t="1e"/></sensml>`,
+			err: `senml: invalid XML: record 0: attribute t: invalid number "1e"`,
+		},
+		{
+			xml: `<sensml xmlns="urn:ietf:params:xml:ns:senml"><senml n="foo" t="."/></sensml>`,
+			err: `senml: invalid XML: record 0: attribute t: invalid number "."`,
+		},
+	}
+	for _, tc := range tcs {
+		var p Pack
+		err := xml.Unmarshal([]byte(tc.xml), &p)
+		if _, ok := err.(*XMLError); !ok || err.Error() != tc.err {
+			t.Errorf("XML decoding of %s should return %q not %v", tc.xml, tc.err, err)
+		}
+	}
+
+	src := `<?xml version="1.0"?>
+<sensml xmlns="urn:ietf:params:xml:ns:senml" xmlns:x="urn:foo">
+	<!-- comment -->
+	<senml xmlns="urn:ietf:params:xml:ns:senml" n="foo" v="1" vb="1" x:foo="bar" foo="bar"/>
+	<senml n="bar" t="-.5E+3" vb="false"></senml>
+</sensml>`
+	p, err := DecodeXML([]byte(src), XMLOptions{})
+	if err != nil {
+		t.Fatalf("XML decoding of %s returned an error : %s", src, err)
+	}
+	if exp := (Pack{{Name: "foo", Value: Float(1), BoolValue: True}, {Name: "bar", Time: -500, BoolValue: False}}); !exp.Equals(p) || p[0].BoolValue == nil || !*p[0].BoolValue {
+		t.Errorf("XML decoding of %s should be %+v not %+v", src, exp, p)
+	}
+	if _, err := DecodeXML([]byte(`<!-- empty -->`), XMLOptions{}); err == nil {
+		t.Errorf("XML decoding of a document without root element should return an error")
+	}
+}
+
+func TestXMLLenient(t *testing.T) {
+	tcs := []string{
+		`<senml><senml n="foo" v=" 1 " vb="T" foo="bar"/></senml>`,
+		`<sensml xmlns="urn:foo"><record/><senml n="foo" v="1" vb="true">text<foo/></senml></sensml>`,
+		`<sensml><senml n="foo" v="0x1p0" vb="true"/></sensml>`,
+	}
+	exp := Pack{{Name: "foo", Value: Float(1), BoolValue: True}}
+	for _, tc := range tcs {
+		p, err := DecodeXML([]byte(tc), XMLOptions{Lenient: true})
+		if err != nil {
+			t.Errorf("lenient XML decoding of %s returned an error : %s", tc, err)
+			continue
+		}
+		if !exp.Equals(p) || p[0].BoolValue == nil || !*p[0].BoolValue {
+			t.Errorf("lenient XML decoding of %s should be %+v not %+v", tc, exp, p)
+		}
+	}
+	if _, err := DecodeXML([]byte(`<sensml><senml v="abc"/></sensml>`), XMLOptions{Lenient: true}); err == nil {
+		t.Errorf("lenient XML decoding of an invalid number should return an error")
+	}
+	if _, err := DecodeXML([]byte(`<sensml><senml v="1" foo_="bar"/></sensml>`), XMLOptions{Lenient: true}); err == nil {
+		t.Errorf("lenient XML decoding of a must-understand attribute should return an error")
+	}
+}